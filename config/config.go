@@ -1,5 +1,14 @@
 package config
 
+import (
+	"crypto"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/zeitlos/knockknock/release"
+)
+
 type Config struct {
 	BinaryName  string
 	BinaryDir   string
@@ -7,22 +16,252 @@ type Config struct {
 	Repo        string
 	Version     string
 
-	Auth *AuthConfig
+	// Source is where releases are taken from instead of Repo, e.g. an
+	// HTTPS index or a local directory
+	Source release.Source
+
+	// Mirrors are repositories holding copies of the releases in Repo,
+	// tried in order when Repo is unavailable
+	Mirrors []Mirror
+
+	// LayoutDir is a directory of OCI image layouts releases are taken from
+	// instead of Repo
+	LayoutDir string
+
+	Auth        *AuthConfig
+	Transport   *TransportConfig
+	AutoUpdate  *AutoUpdateConfig
+	Maintenance *MaintenanceConfig
+	Probation   *ProbationConfig
+	RestartMode RestartMode
+
+	// Channel is the name of the release channel updates are taken from
+	Channel string
+
+	// Channels are the release channels available to select from
+	Channels []Channel
+
+	// Constraint is a semver constraint (e.g. "~1.4" or ">=1.2, <2") that
+	// versions must satisfy to be installed
+	Constraint string
+
+	// ShutdownSignal is sent to the child to ask it to shut down
+	ShutdownSignal syscall.Signal
+
+	// ShutdownGracePeriod is how long the child may take to exit after
+	// ShutdownSignal before it is killed
+	ShutdownGracePeriod time.Duration
+
+	// VersionCacheTTL is how long listed versions are reused before the
+	// source is asked again, 0 lists versions on every check
+	VersionCacheTTL time.Duration
+
+	// DownloadRateLimit caps the download speed in bytes per second, 0 is
+	// unlimited
+	DownloadRateLimit int64
+
+	// RolloutSeed determines the host's bucket in phased rollouts. Default:
+	// the machine ID, or the hostname if there is none
+	RolloutSeed string
+
+	// SigningKeys are the ed25519 or ECDSA public keys release binaries must
+	// be signed with. Signature verification is disabled when empty.
+	SigningKeys []crypto.PublicKey
 }
 
+// AuthConfig holds the credentials for the OCI registry configured via
+// WithRepo. Either Username and Password (basic auth) or a token can be set.
+// Registries not matching the repository fall back to the Docker credential
+// store.
 type AuthConfig struct {
 	Username string
 	Password string
-	Token    string
+
+	// Token is a bearer (access) token sent directly to the registry.
+	Token string
+
+	// RefreshToken is an identity token exchanged with the registry's
+	// authorization service for short-lived access tokens.
+	RefreshToken string
+}
+
+// Mirror is a repository holding copies of the releases in the configured
+// repository, e.g. a pull-through cache or a registry in another region.
+type Mirror struct {
+	Repo string
+
+	// Auth holds the credentials for the mirror. Default: the Docker
+	// credential store
+	Auth *AuthConfig
+
+	// Transport configures the connection to the mirror. Default: the
+	// transport set via WithTransport
+	Transport *TransportConfig
+}
+
+// TransportConfig configures the connection to the OCI registry. Unset
+// fields keep the defaults of net/http.
+type TransportConfig struct {
+	// CAFile is a PEM bundle of root CAs trusted in addition to the system
+	// roots, e.g. for a registry with a private CA
+	CAFile string
+
+	// CertFile and KeyFile are a PEM client certificate and key presented
+	// for mutual TLS
+	CertFile string
+	KeyFile  string
+
+	// InsecureSkipVerify disables verification of the registry's
+	// certificate. Only meant for development.
+	InsecureSkipVerify bool
+
+	// PlainHTTP talks to the registry over HTTP instead of HTTPS
+	PlainHTTP bool
+
+	// Proxy is the URL of the HTTP proxy requests are sent through.
+	// Default: the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment
+	// variables
+	Proxy string
+
+	// DialTimeout limits establishing a connection
+	DialTimeout time.Duration
+
+	// TLSHandshakeTimeout limits the TLS handshake
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout limits waiting for the response headers of a
+	// request. Response bodies are not limited, as downloads take as long
+	// as they take.
+	ResponseHeaderTimeout time.Duration
 }
 
+// UpdatePolicy limits which newer versions the auto-updater installs,
+// relative to the currently running version.
+type UpdatePolicy string
+
+const (
+	// UpdatePolicyLatest installs the newest available version.
+	UpdatePolicyLatest UpdatePolicy = "latest"
+
+	// UpdatePolicyMinor installs minor and patch releases within the
+	// current major version.
+	UpdatePolicyMinor UpdatePolicy = "minor"
+
+	// UpdatePolicyPatch installs patch releases within the current minor
+	// version.
+	UpdatePolicyPatch UpdatePolicy = "patch"
+)
+
+// DefaultShutdownGracePeriod is how long the child may take to exit after
+// the shutdown signal unless configured otherwise.
+const DefaultShutdownGracePeriod = 30 * time.Second
+
+// RestartMode controls how the supervisor restarts after an update or
+// rollback switched the active version.
+type RestartMode string
+
+const (
+	// RestartModeSignal terminates the supervisor with SIGTERM and relies on
+	// a process manager such as systemd to start the new version.
+	RestartModeSignal RestartMode = "signal"
+
+	// RestartModeExec stops the child and replaces the supervisor in place
+	// with the new binary via exec(2), keeping PID, arguments, environment
+	// and the IPC socket. No process manager is required.
+	RestartModeExec RestartMode = "exec"
+)
+
+// AutoUpdateConfig configures the supervisor's background update poller.
+type AutoUpdateConfig struct {
+	Enabled bool
+
+	// Interval between registry polls. Default: 1 hour
+	Interval time.Duration
+
+	// Jitter is the maximum random delay added to each interval so a fleet
+	// does not poll the registry in lockstep. Default: 10% of Interval
+	Jitter time.Duration
+
+	// Policy restricts which versions are installed. Default: UpdatePolicyLatest
+	Policy UpdatePolicy
+}
+
+// Channel is a release channel. Stable releases are part of every channel,
+// prereleases only if their first prerelease identifier is listed in
+// Prereleases (e.g. "rc" for 1.2.0-rc.1). The wildcard "*" includes all
+// prereleases.
+type Channel struct {
+	Name        string
+	Prereleases []string
+}
+
+var (
+	// ChannelStable only includes stable releases.
+	ChannelStable = Channel{Name: "stable"}
+
+	// ChannelBeta includes beta and release candidate prereleases.
+	ChannelBeta = Channel{Name: "beta", Prereleases: []string{"beta", "rc"}}
+
+	// ChannelNightly includes every prerelease.
+	ChannelNightly = Channel{Name: "nightly", Prereleases: []string{"*"}}
+)
+
+// MaintenanceConfig restricts automatic updates to maintenance windows.
+// Outside of a window, eligible updates are only staged; they are activated
+// once a window opens. Manual updates, rollbacks and activations are not
+// restricted.
+type MaintenanceConfig struct {
+	// Windows in which updates may be activated, as optional weekdays
+	// followed by a time range, e.g. "Tue-Thu 02:00-04:00",
+	// "Sat,Sun 00:00-06:00" or "22:00-02:00" (every day, wrapping past
+	// midnight).
+	Windows []string
+
+	// Location the windows are evaluated in. Default: time.Local
+	Location *time.Location
+}
+
+// ProbationConfig configures health-gated updates. After an update the new
+// version must prove to be healthy before the deadline, otherwise it is
+// marked as failed and rolled back. A version is healthy once the
+// application reports ready via the IPC client, or once all configured
+// probes succeed.
+type ProbationConfig struct {
+	// Deadline for the new version to become healthy. Default: 2 minutes
+	Deadline time.Duration
+
+	// Interval between probe runs, also used as probe timeout. Default: 5 seconds
+	Interval time.Duration
+
+	// HTTPProbe is a URL that must respond with a 2xx status code
+	HTTPProbe string
+
+	// ExecProbe is a command (and its arguments) that must exit with code 0
+	ExecProbe []string
+}
+
+// Defaults for probation settings left zero.
+const (
+	DefaultProbationDeadline = 2 * time.Minute
+	DefaultProbationInterval = 5 * time.Second
+)
+
 // New creates a new Config with the given binary name.
-// BinaryDir defaults to "/usr/local/bin", VersionsDir defaults to "/usr/local/lib".
+// BinaryDir defaults to "/usr/local/bin", VersionsDir defaults to "/usr/local/lib",
+// RestartMode to RestartModeSignal and the child is given 30 seconds to exit
+// after SIGTERM on shutdown. Updates are taken from the stable channel, with
+// the beta and nightly channels available.
 func New(binaryName string) *Config {
 	return &Config{
-		BinaryName:  binaryName,
-		BinaryDir:   "/usr/local/bin",
-		VersionsDir: "/usr/local/lib",
+		BinaryName:          binaryName,
+		BinaryDir:           "/usr/local/bin",
+		VersionsDir:         "/usr/local/lib",
+		Channel:             ChannelStable.Name,
+		Channels:            []Channel{ChannelStable, ChannelBeta, ChannelNightly},
+		RestartMode:         RestartModeSignal,
+		ShutdownSignal:      syscall.SIGTERM,
+		ShutdownGracePeriod: DefaultShutdownGracePeriod,
+		VersionCacheTTL:     time.Minute,
 	}
 }
 
@@ -33,6 +272,33 @@ func (c *Config) WithRepo(repo string) *Config {
 	return c
 }
 
+// WithSource sets the source to pull updates from instead of an OCI
+// registry, see release.NewHTTPSource and release.NewDirSource.
+func (c *Config) WithSource(source release.Source) *Config {
+	c.Source = source
+	return c
+}
+
+// WithMirrors adds repositories mirroring the repository set via WithRepo.
+// Requests go to the repository and fail over to the mirrors in order while
+// it is unavailable. Tags are only resolved by the repository, and content
+// from mirrors is verified against the digest they resolve to, so mirrors
+// can't serve different content. While the repository is down, no new
+// versions are resolved.
+func (c *Config) WithMirrors(mirrors ...Mirror) *Config {
+	c.Mirrors = append(c.Mirrors, mirrors...)
+	return c
+}
+
+// WithLayoutDir takes releases from OCI image layouts in dir instead of a
+// registry, for hosts without registry access. The directory is an image
+// layout itself, or holds image layout directories and oci-layout
+// tarballs (*.tar), e.g. created with "oras copy --to-oci-layout".
+func (c *Config) WithLayoutDir(dir string) *Config {
+	c.LayoutDir = dir
+	return c
+}
+
 // WithVersion sets the current version of the binary.
 // This should typically be set at build time via ldflags.
 func (c *Config) WithVersion(version string) *Config {
@@ -46,6 +312,145 @@ func (c *Config) WithAuth(auth *AuthConfig) *Config {
 	return c
 }
 
+// WithTransport configures the connection to the OCI registry, e.g. a
+// private CA, client certificates, plain HTTP or a proxy.
+func (c *Config) WithTransport(transport *TransportConfig) *Config {
+	c.Transport = transport
+	return c
+}
+
+// WithChannel sets the release channel updates are taken from. The channel
+// can be switched at runtime through the IPC client.
+// Default: "stable"
+func (c *Config) WithChannel(name string) *Config {
+	c.Channel = name
+	return c
+}
+
+// WithChannels adds custom release channels, replacing built-in channels
+// of the same name.
+func (c *Config) WithChannels(channels ...Channel) *Config {
+	for _, channel := range channels {
+		c.Channels = slices.DeleteFunc(c.Channels, func(existing Channel) bool {
+			return existing.Name == channel.Name
+		})
+
+		c.Channels = append(c.Channels, channel)
+	}
+
+	return c
+}
+
+// WithConstraint restricts updates to versions satisfying the given semver
+// constraint, e.g. "~1.4" for patch releases of 1.4 or ">=1.2, <2" to never
+// cross a major version. Forced updates bypass the constraint.
+func (c *Config) WithConstraint(constraint string) *Config {
+	c.Constraint = constraint
+	return c
+}
+
+// WithSigningKeys sets the public keys used to verify release signatures.
+// Supported are ed25519.PublicKey and *ecdsa.PublicKey. Once set, versions
+// without a valid signature are refused.
+func (c *Config) WithSigningKeys(keys ...crypto.PublicKey) *Config {
+	c.SigningKeys = append(c.SigningKeys, keys...)
+	return c
+}
+
+// WithAutoUpdate enables the supervisor's background update poller.
+// Zero values in the given config are replaced with their defaults.
+func (c *Config) WithAutoUpdate(autoUpdate *AutoUpdateConfig) *Config {
+	if autoUpdate.Interval <= 0 {
+		autoUpdate.Interval = time.Hour
+	}
+
+	if autoUpdate.Jitter <= 0 {
+		autoUpdate.Jitter = autoUpdate.Interval / 10
+	}
+
+	if autoUpdate.Policy == "" {
+		autoUpdate.Policy = UpdatePolicyLatest
+	}
+
+	c.AutoUpdate = autoUpdate
+	return c
+}
+
+// WithMaintenance restricts automatic updates to maintenance windows.
+// Zero values in the given config are replaced with their defaults.
+func (c *Config) WithMaintenance(maintenance *MaintenanceConfig) *Config {
+	if maintenance.Location == nil {
+		maintenance.Location = time.Local
+	}
+
+	c.Maintenance = maintenance
+	return c
+}
+
+// WithVersionCacheTTL sets how long listed versions are reused before the
+// registry is asked again. Once expired, the cached list keeps being served
+// while it is refreshed in the background, and while the registry fails.
+// A TTL of 0 lists versions on every check.
+// Default: 1 minute
+func (c *Config) WithVersionCacheTTL(ttl time.Duration) *Config {
+	c.VersionCacheTTL = ttl
+	return c
+}
+
+// WithDownloadRateLimit caps the download speed of updates in bytes per
+// second, e.g. to spare slow links.
+// Default: unlimited
+func (c *Config) WithDownloadRateLimit(bytesPerSecond int64) *Config {
+	c.DownloadRateLimit = bytesPerSecond
+	return c
+}
+
+// WithRolloutSeed sets the seed the host's phased rollout bucket is derived
+// from. Hosts with the same seed always receive a release at the same time.
+// Default: the machine ID, or the hostname if there is none
+func (c *Config) WithRolloutSeed(seed string) *Config {
+	c.RolloutSeed = seed
+	return c
+}
+
+// WithProbation enables health-gated updates.
+// Zero values in the given config are replaced with their defaults.
+func (c *Config) WithProbation(probation *ProbationConfig) *Config {
+	if probation.Deadline <= 0 {
+		probation.Deadline = DefaultProbationDeadline
+	}
+
+	if probation.Interval <= 0 {
+		probation.Interval = DefaultProbationInterval
+	}
+
+	c.Probation = probation
+	return c
+}
+
+// WithRestartMode sets how the supervisor restarts into a new version
+// Default: RestartModeSignal
+func (c *Config) WithRestartMode(mode RestartMode) *Config {
+	c.RestartMode = mode
+	return c
+}
+
+// WithShutdownSignal sets the signal sent to the child before updates,
+// rollbacks and when the supervisor itself is stopped
+// Default: SIGTERM
+func (c *Config) WithShutdownSignal(signal syscall.Signal) *Config {
+	c.ShutdownSignal = signal
+	return c
+}
+
+// WithShutdownGracePeriod sets how long the child may take to exit after the
+// shutdown signal before it is killed with SIGKILL
+// Default: 30 seconds
+func (c *Config) WithShutdownGracePeriod(gracePeriod time.Duration) *Config {
+	c.ShutdownGracePeriod = gracePeriod
+	return c
+}
+
 // WithBinaryDir sets the directory where the executable symlink will be placed
 // Default: "/usr/local/bin"
 func (c *Config) WithBinaryDir(dir string) *Config {
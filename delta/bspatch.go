@@ -0,0 +1,158 @@
+// Package delta applies binary patches in the bsdiff 4 format, as produced
+// by the bsdiff tool.
+package delta
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	magic      = "BSDIFF40"
+	headerSize = 32
+	chunkSize  = 32 * 1024
+)
+
+// ErrCorruptPatch is returned for patches that are malformed or do not match
+// the old file.
+var ErrCorruptPatch = errors.New("corrupt patch")
+
+// Apply reconstructs the new file from old and a bsdiff 4 patch and writes it
+// to dst. Both inputs are read with random access so that neither has to be
+// held in memory.
+func Apply(dst io.Writer, old io.ReaderAt, oldSize int64, patch io.ReaderAt, patchSize int64) error {
+	header := make([]byte, headerSize)
+
+	if _, err := patch.ReadAt(header, 0); err != nil {
+		return fmt.Errorf("failed to read patch header: %w", err)
+	}
+
+	if string(header[:8]) != magic {
+		return fmt.Errorf("%w: not a bsdiff 4 patch", ErrCorruptPatch)
+	}
+
+	ctrlLen := offtin(header[8:16])
+	diffLen := offtin(header[16:24])
+	newSize := offtin(header[24:32])
+
+	// The lengths are compared against what is left of the patch rather
+	// than summed up, which could overflow
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || patchSize < headerSize ||
+		ctrlLen > patchSize-headerSize || diffLen > patchSize-headerSize-ctrlLen {
+		return fmt.Errorf("%w: invalid header", ErrCorruptPatch)
+	}
+
+	ctrl := bzip2.NewReader(io.NewSectionReader(patch, headerSize, ctrlLen))
+	diff := bzip2.NewReader(io.NewSectionReader(patch, headerSize+ctrlLen, diffLen))
+	extra := bzip2.NewReader(io.NewSectionReader(patch, headerSize+ctrlLen+diffLen, patchSize-headerSize-ctrlLen-diffLen))
+
+	out := bufio.NewWriter(dst)
+	newBuf := make([]byte, chunkSize)
+	oldBuf := make([]byte, chunkSize)
+	control := make([]byte, 24)
+
+	var newPos, oldPos int64
+
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, control); err != nil {
+			return fmt.Errorf("%w: failed to read control block: %v", ErrCorruptPatch, err)
+		}
+
+		// Bytes to add to old, bytes to copy from extra, seek in old
+		add := offtin(control[0:8])
+		copyLen := offtin(control[8:16])
+		seek := offtin(control[16:24])
+
+		if add < 0 || copyLen < 0 || add > newSize-newPos || copyLen > newSize-newPos-add {
+			return fmt.Errorf("%w: invalid control entry", ErrCorruptPatch)
+		}
+
+		// oldPos advances by add and then seek, both must stay in range
+		if _, ok := addOffsets(oldPos, add); !ok {
+			return fmt.Errorf("%w: invalid control entry", ErrCorruptPatch)
+		}
+
+		for remaining := add; remaining > 0; {
+			n := min(remaining, chunkSize)
+
+			if _, err := io.ReadFull(diff, newBuf[:n]); err != nil {
+				return fmt.Errorf("%w: failed to read diff block: %v", ErrCorruptPatch, err)
+			}
+
+			if err := readOld(old, oldSize, oldBuf[:n], oldPos); err != nil {
+				return err
+			}
+
+			for i := range n {
+				newBuf[i] += oldBuf[i]
+			}
+
+			if _, err := out.Write(newBuf[:n]); err != nil {
+				return fmt.Errorf("failed to write patched file: %w", err)
+			}
+
+			remaining -= n
+			newPos += n
+			oldPos += n
+		}
+
+		if _, err := io.CopyN(out, extra, copyLen); err != nil {
+			return fmt.Errorf("%w: failed to read extra block: %v", ErrCorruptPatch, err)
+		}
+
+		newPos += copyLen
+
+		var ok bool
+
+		if oldPos, ok = addOffsets(oldPos, seek); !ok {
+			return fmt.Errorf("%w: invalid control entry", ErrCorruptPatch)
+		}
+	}
+
+	return out.Flush()
+}
+
+// readOld fills buf from old at offset. Bytes outside of old read as zero,
+// as bsdiff only adds old bytes that exist.
+func readOld(old io.ReaderAt, oldSize int64, buf []byte, offset int64) error {
+	clear(buf)
+
+	if offset >= oldSize || offset <= -int64(len(buf)) {
+		return nil
+	}
+
+	start := max(offset, 0)
+	end := min(offset+int64(len(buf)), oldSize)
+
+	if _, err := old.ReadAt(buf[start-offset:end-offset], start); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read old file: %w", err)
+	}
+
+	return nil
+}
+
+// addOffsets returns a+b, and false if the sum overflows.
+func addOffsets(a, b int64) (int64, bool) {
+	sum := a + b
+
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, false
+	}
+
+	return sum, true
+}
+
+// offtin decodes bsdiff's sign-magnitude little endian integers.
+func offtin(buf []byte) int64 {
+	y := int64(binary.LittleEndian.Uint64(buf) &^ (1 << 63))
+
+	if buf[7]&0x80 != 0 {
+		y = -y
+	}
+
+	return y
+}
@@ -0,0 +1,136 @@
+package delta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fixtures in testdata are written by testdata/mkpatch.py.
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func apply(old, patch []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	err := Apply(&out, bytes.NewReader(old), int64(len(old)), bytes.NewReader(patch), int64(len(patch)))
+
+	return out.Bytes(), err
+}
+
+func TestApply(t *testing.T) {
+	old := readFixture(t, "old.bin")
+	want := readFixture(t, "new.bin")
+
+	got, err := apply(old, readFixture(t, "roundtrip.patch"))
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Apply() produced %d bytes that differ from the %d expected", len(got), len(want))
+	}
+}
+
+// withHeader returns patch with the header lengths replaced.
+func withHeader(patch []byte, ctrlLen, diffLen, newSize uint64) []byte {
+	patched := bytes.Clone(patch)
+
+	binary.LittleEndian.PutUint64(patched[8:16], ctrlLen)
+	binary.LittleEndian.PutUint64(patched[16:24], diffLen)
+	binary.LittleEndian.PutUint64(patched[24:32], newSize)
+
+	return patched
+}
+
+func TestApplyCorruptPatch(t *testing.T) {
+	old := readFixture(t, "old.bin")
+	patch := readFixture(t, "roundtrip.patch")
+
+	ctrlLen := binary.LittleEndian.Uint64(patch[8:16])
+	diffLen := binary.LittleEndian.Uint64(patch[16:24])
+	newSize := binary.LittleEndian.Uint64(patch[24:32])
+
+	tests := []struct {
+		name    string
+		patch   []byte
+		wantErr string
+	}{
+		{name: "empty", patch: nil, wantErr: "failed to read patch header"},
+		{name: "bad magic", patch: append([]byte("BSDIFF41"), patch[8:]...), wantErr: "not a bsdiff 4 patch"},
+		{name: "truncated header", patch: patch[:20], wantErr: "failed to read patch header"},
+		{name: "truncated control block", patch: patch[:headerSize+ctrlLen/2], wantErr: "invalid header"},
+		{name: "truncated diff block", patch: patch[:headerSize+ctrlLen+diffLen/2], wantErr: "invalid header"},
+		{name: "truncated extra block", patch: patch[:(uint64(len(patch))+headerSize+ctrlLen+diffLen)/2], wantErr: "failed to read extra block"},
+		{name: "negative control length", patch: withHeader(patch, 1<<63|ctrlLen, diffLen, newSize), wantErr: "invalid header"},
+		{name: "negative new size", patch: withHeader(patch, ctrlLen, diffLen, 1<<63|newSize), wantErr: "invalid header"},
+		{name: "oversized control length", patch: withHeader(patch, uint64(len(patch)), diffLen, newSize), wantErr: "invalid header"},
+		{name: "overflowing lengths", patch: withHeader(patch, math.MaxInt64, math.MaxInt64-uint64(headerSize)+2, newSize), wantErr: "invalid header"},
+		{name: "overflowing diff length", patch: withHeader(patch, ctrlLen, math.MaxInt64, newSize), wantErr: "invalid header"},
+		{name: "oversized new size", patch: withHeader(patch, ctrlLen, diffLen, newSize+1), wantErr: "failed to read control block"},
+		{name: "negative add", patch: readFixture(t, "negative-add.patch"), wantErr: "invalid control entry"},
+		{name: "negative copy", patch: readFixture(t, "negative-copy.patch"), wantErr: "invalid control entry"},
+		{name: "oversized add", patch: readFixture(t, "oversized-add.patch"), wantErr: "invalid control entry"},
+		{name: "overflowing control entry", patch: readFixture(t, "overflowing-control.patch"), wantErr: "invalid control entry"},
+		{name: "overflowing seek", patch: readFixture(t, "overflowing-seek.patch"), wantErr: "invalid control entry"},
+		{name: "short control block", patch: readFixture(t, "short-control.patch"), wantErr: "failed to read control block"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := apply(old, tt.patch)
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if tt.wantErr != "failed to read patch header" && !errors.Is(err, ErrCorruptPatch) {
+				t.Errorf("Apply() error = %v, want ErrCorruptPatch", err)
+			}
+		})
+	}
+}
+
+func TestReadOld(t *testing.T) {
+	old := []byte("0123456789")
+
+	tests := []struct {
+		offset int64
+		size   int
+		want   string
+	}{
+		{offset: 0, size: 4, want: "0123"},
+		{offset: 8, size: 4, want: "89\x00\x00"},
+		{offset: -2, size: 4, want: "\x00\x0001"},
+		{offset: -4, size: 4, want: "\x00\x00\x00\x00"},
+		{offset: 10, size: 4, want: "\x00\x00\x00\x00"},
+		{offset: math.MaxInt64 - 1, size: 4, want: "\x00\x00\x00\x00"},
+		{offset: math.MinInt64, size: 4, want: "\x00\x00\x00\x00"},
+	}
+
+	for _, tt := range tests {
+		buf := bytes.Repeat([]byte{0xff}, tt.size)
+
+		if err := readOld(bytes.NewReader(old), int64(len(old)), buf, tt.offset); err != nil {
+			t.Fatalf("readOld(%d) error = %v", tt.offset, err)
+		}
+
+		if string(buf) != tt.want {
+			t.Errorf("readOld(%d) = %q, want %q", tt.offset, buf, tt.want)
+		}
+	}
+}
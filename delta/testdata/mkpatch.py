@@ -0,0 +1,61 @@
+#!/usr/bin/env python3
+"""Writes the bsdiff 4 fixtures of the delta tests.
+
+The round trip fixture turns old.bin into new.bin by changing, inserting,
+skipping and appending bytes, so that Apply has to add, copy and seek. The
+control fixtures each carry a single malformed control entry.
+"""
+
+import bz2
+import random
+import struct
+
+
+def offtout(x):
+    if x < 0:
+        return struct.pack("<Q", (-x) | (1 << 63))
+    return struct.pack("<Q", x)
+
+
+def patch(controls, diff, extra, new_size):
+    ctrl = bz2.compress(b"".join(offtout(a) + offtout(c) + offtout(s) for a, c, s in controls))
+    diff = bz2.compress(diff)
+    extra = bz2.compress(extra)
+    header = b"BSDIFF40" + offtout(len(ctrl)) + offtout(len(diff)) + offtout(new_size)
+    return header + ctrl + diff + extra
+
+
+def write(name, data):
+    with open(name, "wb") as f:
+        f.write(data)
+
+
+rng = random.Random(4)
+old = bytes(rng.randrange(256) for _ in range(100_000))
+
+# new = old[:40000] with every 1000th byte incremented, 500 inserted bytes,
+# old[45000:90000], 2000 appended bytes
+first = bytearray(old[:40000])
+for i in range(0, len(first), 1000):
+    first[i] = (first[i] + 1) % 256
+inserted = bytes(rng.randrange(256) for _ in range(500))
+second = old[45000:90000]
+appended = bytes(rng.randrange(256) for _ in range(2000))
+new = bytes(first) + inserted + second + appended
+
+diff = bytes((n - o) % 256 for n, o in zip(first, old[:40000])) + bytes(len(second))
+controls = [
+    (len(first), len(inserted), 5000),
+    (len(second), len(appended), 0),
+]
+
+write("old.bin", old)
+write("new.bin", new)
+write("roundtrip.patch", patch(controls, diff, inserted + appended, len(new)))
+
+write("negative-add.patch", patch([(-1, 0, 0)], b"", b"", 10))
+write("negative-copy.patch", patch([(0, -1, 0)], b"", b"", 10))
+write("oversized-add.patch", patch([(11, 0, 0)], bytes(11), b"", 10))
+write("overflowing-control.patch", patch([(1 << 62, (1 << 63) - 1, 0)], b"", b"", 10))
+write("overflowing-seek.patch", patch([(1, 0, (1 << 63) - 1), (1, 0, 0)], bytes(2), b"", 2))
+write("short-control.patch", patch([(2, 0, 0)], bytes(2), b"", 10))
@@ -47,7 +47,19 @@ func run() {
 	log.Printf("%s v%s starting on %s", AppName, Version, addr)
 	log.Printf("Health endpoint: http://localhost%s/health", addr)
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	server := &http.Server{Addr: addr}
+
+	// Drain in-flight requests before knockknock switches versions
+	knockknock.OnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("failed to shut down server", "error", err)
+		}
+	})
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
@@ -78,7 +90,7 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 
 	historyHTML := ""
 	for _, entry := range history {
-		historyHTML += fmt.Sprintf("<li>%s <br />%s</li>", entry.Version, entry.LastInstalled.Format(time.DateTime))
+		historyHTML += fmt.Sprintf("<li>%s → %s (%s, %s) <br />%s</li>", entry.From, entry.To, entry.Trigger, entry.Outcome, entry.Time.Format(time.DateTime))
 	}
 
 	newVersionClass := ""
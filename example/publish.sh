@@ -25,10 +25,58 @@ echo ""
 echo "Publishing ${BINARY_NAME} to ${IMAGE_REF}"
 echo ""
 
+# Optionally limit the release to a percentage of hosts. Raise it later by
+# publishing the same version again with a higher ROLLOUT.
+ANNOTATIONS=()
+if [ -n "${ROLLOUT:-}" ]; then
+    ANNOTATIONS+=(--annotation "dev.knockknock.rollout=${ROLLOUT}")
+fi
+
 # Push the binary using ORAS
-oras push "${IMAGE_REF}" \
+oras push "${IMAGE_REF}" "${ANNOTATIONS[@]}" \
     "${BINARY_NAME}:application/vnd.unknown.layer.v1+binary"
 
+# Optionally sign the release and attach the signature as a referrer. The
+# signature covers the sha256sum listing of all pushed files, sorted by path.
+# SIGNING_KEY points to an ed25519 or ECDSA private key in PEM format.
+if [ -n "${SIGNING_KEY:-}" ]; then
+    echo ""
+    echo "Signing ${BINARY_NAME} with ${SIGNING_KEY}"
+
+    sha256sum "${BINARY_NAME}" | LC_ALL=C sort -k 2 | openssl dgst -sha256 -binary > "${BINARY_NAME}.sha256"
+
+    if openssl pkey -in "${SIGNING_KEY}" -noout -text | grep -q "ED25519"; then
+        openssl pkeyutl -sign -inkey "${SIGNING_KEY}" -rawin -in "${BINARY_NAME}.sha256" -out "${BINARY_NAME}.sig"
+    else
+        openssl pkeyutl -sign -inkey "${SIGNING_KEY}" -in "${BINARY_NAME}.sha256" -out "${BINARY_NAME}.sig"
+    fi
+
+    oras attach "${IMAGE_REF}" \
+        --artifact-type application/vnd.knockknock.signature.v1 \
+        "${BINARY_NAME}.sig:application/vnd.knockknock.signature.v1"
+
+    rm "${BINARY_NAME}.sha256" "${BINARY_NAME}.sig"
+fi
+
+# Optionally publish a binary patch from a previous version, so hosts
+# running PATCH_BASE only download the difference. Requires bsdiff.
+if [ -n "${PATCH_BASE:-}" ]; then
+    echo ""
+    echo "Creating patch from ${PATCH_BASE}"
+
+    mkdir -p patch-base patch
+    oras pull "${REGISTRY}/${BINARY_NAME}:${PATCH_BASE}" -o patch-base
+    bsdiff "patch-base/${BINARY_NAME}" "${BINARY_NAME}" "patch/${BINARY_NAME}"
+
+    # The layer title must match the file the patch produces
+    (cd patch && oras attach "${IMAGE_REF}" \
+        --artifact-type application/vnd.knockknock.patch.v1 \
+        --annotation "dev.knockknock.patch.base=${PATCH_BASE}" \
+        "${BINARY_NAME}:application/vnd.knockknock.patch.v1")
+
+    rm -r patch-base patch
+fi
+
 rm $BINARY_NAME
 
 echo ""
@@ -6,7 +6,7 @@ require oras.land/oras-go/v2 v2.6.0
 
 require (
 	github.com/Masterminds/semver/v3 v3.4.0
-	github.com/opencontainers/go-digest v1.0.0 // indirect
-	github.com/opencontainers/image-spec v1.1.1 // indirect
-	golang.org/x/sync v0.14.0 // indirect
+	github.com/opencontainers/go-digest v1.0.0
+	github.com/opencontainers/image-spec v1.1.1
+	golang.org/x/sync v0.14.0
 )
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -37,7 +38,19 @@ func NewClient(socketPath string) (*Client, error) {
 }
 
 func (c *Client) Versions(ctx context.Context) ([]semver.Version, error) {
-	resp, err := c.versions()
+	resp, err := c.versions(false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Versions, nil
+}
+
+// RefreshVersions is like Versions but lists the versions from the registry
+// instead of returning the supervisor's cached list.
+func (c *Client) RefreshVersions(ctx context.Context) ([]semver.Version, error) {
+	resp, err := c.versions(true)
 
 	if err != nil {
 		return nil, err
@@ -47,7 +60,17 @@ func (c *Client) Versions(ctx context.Context) ([]semver.Version, error) {
 }
 
 func (c *Client) CheckForUpdate(ctx context.Context) (*semver.Version, []semver.Version, error) {
-	resp, err := c.versions()
+	return c.checkForUpdate(false)
+}
+
+// RefreshCheckForUpdate is like CheckForUpdate but lists the versions from
+// the registry instead of using the supervisor's cached list.
+func (c *Client) RefreshCheckForUpdate(ctx context.Context) (*semver.Version, []semver.Version, error) {
+	return c.checkForUpdate(true)
+}
+
+func (c *Client) checkForUpdate(refresh bool) (*semver.Version, []semver.Version, error) {
+	resp, err := c.versions(refresh)
 
 	if err != nil {
 		return nil, nil, err
@@ -61,8 +84,19 @@ func (c *Client) CheckForUpdate(ctx context.Context) (*semver.Version, []semver.
 }
 
 func (c *Client) Update(ctx context.Context, version string) error {
+	return c.update(ctx, version, false)
+}
+
+// ForceUpdate is like Update but also installs quarantined versions and
+// versions rejected by the configured constraint.
+func (c *Client) ForceUpdate(ctx context.Context, version string) error {
+	return c.update(ctx, version, true)
+}
+
+func (c *Client) update(ctx context.Context, version string, force bool) error {
 	reqBody := UpdateRequest{
 		Version: version,
+		Force:   force,
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -135,6 +169,187 @@ func (c *Client) Rollback(ctx context.Context) error {
 	return nil
 }
 
+// Stage downloads and verifies a version in the background without
+// activating it. Use Status to see when it is staged, then Activate it.
+func (c *Client) Stage(ctx context.Context, version string) error {
+	return c.stage(ctx, version, false)
+}
+
+// ForceStage is like Stage but also stages quarantined versions and versions
+// rejected by the configured constraint.
+func (c *Client) ForceStage(ctx context.Context, version string) error {
+	return c.stage(ctx, version, true)
+}
+
+func (c *Client) stage(ctx context.Context, version string, force bool) error {
+	body, err := json.Marshal(StageRequest{
+		Version: version,
+		Force:   force,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/stage", bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send stage request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("stage request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var stageResp StageResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&stageResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !stageResp.Success {
+		return fmt.Errorf("stage failed: %s", stageResp.Message)
+	}
+
+	return nil
+}
+
+// Status returns the installed versions, which of them are staged, whether
+// a version is currently being staged and the progress of running downloads.
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/status", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("status request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var statusResp StatusResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &statusResp, nil
+}
+
+// OverrideMaintenance allows automatic updates outside of maintenance
+// windows for the given duration, for emergencies. A duration of zero ends
+// the override.
+func (c *Client) OverrideMaintenance(ctx context.Context, duration time.Duration) error {
+	body, err := json.Marshal(OverrideMaintenanceRequest{
+		Duration: duration,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/maintenance", bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send maintenance request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("maintenance request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var maintenanceResp OverrideMaintenanceResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&maintenanceResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !maintenanceResp.Success {
+		return fmt.Errorf("maintenance override failed: %s", maintenanceResp.Message)
+	}
+
+	return nil
+}
+
+// Activate switches to a version that is already installed, without
+// downloading it again. Quarantined versions are refused.
+func (c *Client) Activate(ctx context.Context, version string) error {
+	return c.activate(ctx, version, false)
+}
+
+// ForceActivate is like Activate but also activates quarantined versions.
+func (c *Client) ForceActivate(ctx context.Context, version string) error {
+	return c.activate(ctx, version, true)
+}
+
+func (c *Client) activate(ctx context.Context, version string, force bool) error {
+	body, err := json.Marshal(ActivateRequest{
+		Version: version,
+		Force:   force,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/activate", bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send activate request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("activate request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var activateResp ActivateResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&activateResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !activateResp.Success {
+		return fmt.Errorf("activate failed: %s", activateResp.Message)
+	}
+
+	return nil
+}
+
 func (c *Client) History(ctx context.Context) ([]HistoryEntry, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/history", nil)
 
@@ -163,8 +378,229 @@ func (c *Client) History(ctx context.Context) ([]HistoryEntry, error) {
 	return historyResp.History, nil
 }
 
-func (c *Client) versions() (*VersionsResponse, error) {
-	resp, err := c.httpClient.Get("http://unix/versions")
+// Verify re-checks an installed version against the digests recorded when it
+// was downloaded. An empty version verifies the currently active one.
+func (c *Client) Verify(ctx context.Context, version string) error {
+	body, err := json.Marshal(VerifyRequest{
+		Version: version,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/verify", bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send verify request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("verify request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var verifyResp VerifyResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&verifyResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !verifyResp.Success {
+		return fmt.Errorf("verification failed: %s", verifyResp.Message)
+	}
+
+	return nil
+}
+
+// Ready reports the application as healthy to the supervisor. After an update
+// with probation enabled, the new version must report ready before the
+// probation deadline or it is rolled back.
+func (c *Client) Ready(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/ready", nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send ready request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ready request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var readyResp ReadyResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&readyResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !readyResp.Success {
+		return fmt.Errorf("ready report failed: %s", readyResp.Message)
+	}
+
+	return nil
+}
+
+// Quarantine lists versions that are not installed again because they
+// crash-looped or failed their probation.
+func (c *Client) Quarantine(ctx context.Context) ([]QuarantineEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/quarantine", nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantine: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("quarantine request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var quarantineResp QuarantineResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&quarantineResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return quarantineResp.Quarantine, nil
+}
+
+// ClearQuarantine releases a version from quarantine. An empty version
+// clears all entries.
+func (c *Client) ClearQuarantine(ctx context.Context, version string) error {
+	endpoint := "http://unix/quarantine?" + url.Values{"version": {version}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send clear quarantine request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("clear quarantine request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var clearResp ClearQuarantineResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&clearResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !clearResp.Success {
+		return fmt.Errorf("clear quarantine failed: %s", clearResp.Message)
+	}
+
+	return nil
+}
+
+// Channel returns the active release channel and all available channels.
+func (c *Client) Channel(ctx context.Context) (string, []string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://unix/channel", nil)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to query channel: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", nil, fmt.Errorf("channel request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var channelResp ChannelResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&channelResp); err != nil {
+		return "", nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return channelResp.Channel, channelResp.Available, nil
+}
+
+// SetChannel switches the release channel updates are taken from.
+func (c *Client) SetChannel(ctx context.Context, channel string) error {
+	body, err := json.Marshal(SetChannelRequest{
+		Channel: channel,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://unix/channel", bytes.NewReader(body))
+
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send channel request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("channel request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var channelResp SetChannelResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&channelResp); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !channelResp.Success {
+		return fmt.Errorf("switching channel failed: %s", channelResp.Message)
+	}
+
+	return nil
+}
+
+func (c *Client) versions(refresh bool) (*VersionsResponse, error) {
+	url := "http://unix/versions"
+
+	if refresh {
+		url += "?refresh=true"
+	}
+
+	resp, err := c.httpClient.Get(url)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to query supervisor: %w", err)
@@ -28,6 +28,7 @@ type VersionsResponse struct {
 
 type UpdateRequest struct {
 	Version string `json:"version"`
+	Force   bool   `json:"force"`
 }
 
 type UpdateResponse struct {
@@ -35,35 +36,149 @@ type UpdateResponse struct {
 	Message string `json:"message"`
 }
 
+type StageRequest struct {
+	Version string `json:"version"`
+	Force   bool   `json:"force"`
+}
+
+type StageResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type StatusResponse struct {
+	Active      string                  `json:"active"`
+	Channel     string                  `json:"channel"`
+	Staging     string                  `json:"staging,omitempty"`
+	Download    *DownloadEntry          `json:"download,omitempty"`
+	Maintenance *MaintenanceEntry       `json:"maintenance,omitempty"`
+	Mirrors     []MirrorEntry           `json:"mirrors,omitempty"`
+	Installed   []InstalledVersionEntry `json:"installed"`
+}
+
+type DownloadEntry struct {
+	Reference      string        `json:"reference"`
+	File           string        `json:"file"`
+	Done           int64         `json:"done"`
+	Total          int64         `json:"total"`
+	BytesPerSecond int64         `json:"bytes_per_second"`
+	ETA            time.Duration `json:"eta"`
+}
+
+type MirrorEntry struct {
+	Repo      string    `json:"repo"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	RetryAt   time.Time `json:"retry_at,omitzero"`
+}
+
+type MaintenanceEntry struct {
+	Open          bool      `json:"open"`
+	NextWindow    time.Time `json:"next_window"`
+	OverrideUntil time.Time `json:"override_until,omitzero"`
+}
+
+type OverrideMaintenanceRequest struct {
+	Duration time.Duration `json:"duration"`
+}
+
+type OverrideMaintenanceResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type InstalledVersionEntry struct {
+	Version     string    `json:"version"`
+	Digest      string    `json:"digest,omitempty"`
+	InstalledAt time.Time `json:"installed_at,omitzero"`
+	Active      bool      `json:"active"`
+	Staged      bool      `json:"staged"`
+}
+
+type ActivateRequest struct {
+	Version string `json:"version"`
+	Force   bool   `json:"force"`
+}
+
+type ActivateResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 type RollbackResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 }
 
+type VerifyRequest struct {
+	Version string `json:"version"`
+}
+
+type VerifyResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type ReadyResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type QuarantineResponse struct {
+	Quarantine []QuarantineEntry `json:"quarantine"`
+}
+
+type QuarantineEntry struct {
+	Version string    `json:"version"`
+	Reason  string    `json:"reason"`
+	Since   time.Time `json:"since"`
+}
+
+type ClearQuarantineResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type ChannelResponse struct {
+	Channel   string   `json:"channel"`
+	Available []string `json:"available"`
+}
+
+type SetChannelRequest struct {
+	Channel string `json:"channel"`
+}
+
+type SetChannelResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 type HistoryResponse struct {
 	History []HistoryEntry `json:"history"`
 }
 
 type HistoryEntry struct {
-	Version       semver.Version `json:"version"`
-	LastInstalled time.Time      `json:"last_installed"`
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Digest  string    `json:"digest,omitempty"`
+	Trigger string    `json:"trigger"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
 }
 
 func NewIPCServer(sv *supervisor.Supervisor) (*Server, error) {
-	socketPath := supervisor.SocketPath()
-
-	// Clean up old socket if exists
-	os.Remove(socketPath)
-
-	listener, err := net.Listen("unix", socketPath)
+	listener, err := sv.Listen()
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to create unix socket: %w", err)
+		return nil, err
 	}
 
 	server := Server{
 		listener:   listener,
-		socketPath: socketPath,
+		socketPath: supervisor.SocketPath(),
 		supervisor: sv,
 	}
 
@@ -76,7 +191,15 @@ func (s *Server) Serve() {
 	mux.HandleFunc("/versions", s.handleVersions)
 	mux.HandleFunc("/update", s.handleUpdate)
 	mux.HandleFunc("/rollback", s.handleRollback)
+	mux.HandleFunc("/stage", s.handleStage)
+	mux.HandleFunc("/activate", s.handleActivate)
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/maintenance", s.handleMaintenance)
 	mux.HandleFunc("/history", s.handleHistory)
+	mux.HandleFunc("/verify", s.handleVerify)
+	mux.HandleFunc("/ready", s.handleReady)
+	mux.HandleFunc("/quarantine", s.handleQuarantine)
+	mux.HandleFunc("/channel", s.handleChannel)
 
 	go func() {
 		if err := http.Serve(s.listener, mux); err != nil {
@@ -94,6 +217,16 @@ func (s *Server) Close() error {
 }
 
 func (s *Server) handleVersions(w http.ResponseWriter, r *http.Request) {
+	// Versions are cached by the supervisor unless a refresh is requested
+	if r.URL.Query().Get("refresh") == "true" {
+		if err := s.supervisor.RefreshVersions(r.Context()); err != nil {
+			slog.Error("failed to refresh versions", "error", err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	update, versions, err := s.supervisor.CheckForUpdate(r.Context())
 
 	if err != nil {
@@ -130,11 +263,27 @@ func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := s.supervisor.ValidateUpdate(req.Version, req.Force); err != nil {
+		slog.Warn("Update refused", "error", err, "version", req.Version)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(UpdateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
 	slog.Info("Updating to version", "version", req.Version)
 
+	update := s.supervisor.Update
+	if req.Force {
+		update = s.supervisor.ForceUpdate
+	}
+
 	// Start update in background - this will kill the process
 	go func() {
-		if err := s.supervisor.Update(context.Background(), req.Version); err != nil {
+		if err := update(context.Background(), req.Version); err != nil {
 			slog.Error("Update failed", "error", err, "version", req.Version)
 		}
 	}()
@@ -174,6 +323,196 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+func (s *Server) handleStage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req StageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.Version == "" {
+		http.Error(w, "Version is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.supervisor.ValidateUpdate(req.Version, req.Force); err != nil {
+		slog.Warn("Staging refused", "error", err, "version", req.Version)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(StageResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	slog.Info("Staging version", "version", req.Version)
+
+	stage := s.supervisor.Stage
+	if req.Force {
+		stage = s.supervisor.ForceStage
+	}
+
+	// Downloads can take a while, progress is reported through /status
+	go func() {
+		if _, err := stage(context.Background(), req.Version); err != nil {
+			slog.Error("Staging failed", "error", err, "version", req.Version)
+		}
+	}()
+
+	response := StageResponse{
+		Success: true,
+		Message: fmt.Sprintf("Staging of version %s initiated", req.Version),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleActivate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ActivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.supervisor.ValidateActivate(req.Version, req.Force); err != nil {
+		slog.Warn("Activation refused", "error", err, "version", req.Version)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ActivateResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	slog.Info("Activating version", "version", req.Version)
+
+	activate := s.supervisor.Activate
+	if req.Force {
+		activate = s.supervisor.ForceActivate
+	}
+
+	// Start activation in background - this will kill the process
+	go func() {
+		if err := activate(req.Version); err != nil {
+			slog.Error("Activation failed", "error", err, "version", req.Version)
+		}
+	}()
+
+	// Return success immediately before process is killed
+	response := ActivateResponse{
+		Success: true,
+		Message: fmt.Sprintf("Activation of version %s initiated, process will restart", req.Version),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.supervisor.Status()
+
+	if err != nil {
+		slog.Error("failed to read status", "error", err)
+
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := StatusResponse{
+		Active:    status.Active,
+		Channel:   status.Channel,
+		Staging:   status.Staging,
+		Installed: make([]InstalledVersionEntry, len(status.Installed)),
+	}
+
+	if d := status.Download; d != nil {
+		resp.Download = &DownloadEntry{
+			Reference:      d.Reference,
+			File:           d.File,
+			Done:           d.Done,
+			Total:          d.Total,
+			BytesPerSecond: d.BytesPerSecond,
+			ETA:            d.ETA,
+		}
+	}
+
+	if m := status.Maintenance; m != nil {
+		resp.Maintenance = &MaintenanceEntry{
+			Open:          m.Open,
+			NextWindow:    m.NextWindow,
+			OverrideUntil: m.OverrideUntil,
+		}
+	}
+
+	for _, m := range status.Mirrors {
+		resp.Mirrors = append(resp.Mirrors, MirrorEntry{
+			Repo:      m.Repo,
+			Healthy:   m.Healthy,
+			Failures:  m.Failures,
+			LastError: m.LastError,
+			RetryAt:   m.RetryAt,
+		})
+	}
+
+	for i, v := range status.Installed {
+		resp.Installed[i] = InstalledVersionEntry{
+			Version:     v.Version,
+			Digest:      v.Digest,
+			InstalledAt: v.InstalledAt,
+			Active:      v.Active,
+			Staged:      v.Staged,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req OverrideMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response := OverrideMaintenanceResponse{
+		Success: true,
+		Message: fmt.Sprintf("Maintenance windows overridden for %s", req.Duration),
+	}
+
+	if req.Duration == 0 {
+		response.Message = "Maintenance override ended"
+	}
+
+	if err := s.supervisor.OverrideMaintenance(req.Duration); err != nil {
+		response = OverrideMaintenanceResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	history := s.supervisor.History()
 
@@ -183,11 +522,155 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 
 	for i, h := range history {
 		resp.History[i] = HistoryEntry{
-			Version:       h.Version,
-			LastInstalled: h.LastInstalled,
+			Time:    h.Time,
+			Action:  string(h.Action),
+			From:    h.From,
+			To:      h.To,
+			Digest:  h.Digest,
+			Trigger: string(h.Trigger),
+			Outcome: string(h.Outcome),
+			Error:   h.Error,
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req VerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	response := VerifyResponse{
+		Success: true,
+		Message: "Version verified",
+	}
+
+	if err := s.supervisor.Verify(req.Version); err != nil {
+		slog.Warn("Verification failed", "error", err, "version", req.Version)
+
+		response = VerifyResponse{
+			Success: false,
+			Message: err.Error(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.supervisor.MarkReady()
+
+	response := ReadyResponse{
+		Success: true,
+		Message: "Ready reported",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		quarantine, err := s.supervisor.Quarantine()
+
+		if err != nil {
+			slog.Error("failed to read quarantine", "error", err)
+
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := QuarantineResponse{
+			Quarantine: make([]QuarantineEntry, len(quarantine)),
+		}
+
+		for i, q := range quarantine {
+			resp.Quarantine[i] = QuarantineEntry{
+				Version: q.Version,
+				Reason:  q.Reason,
+				Since:   q.Since,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodDelete:
+		version := r.URL.Query().Get("version")
+
+		response := ClearQuarantineResponse{
+			Success: true,
+			Message: "Quarantine cleared",
+		}
+
+		if err := s.supervisor.ClearQuarantine(version); err != nil {
+			response = ClearQuarantineResponse{
+				Success: false,
+				Message: err.Error(),
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleChannel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		resp := ChannelResponse{
+			Channel: s.supervisor.Channel().Name,
+		}
+
+		for _, channel := range s.supervisor.Channels() {
+			resp.Available = append(resp.Available, channel.Name)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodPost:
+		var req SetChannelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response := SetChannelResponse{
+			Success: true,
+			Message: fmt.Sprintf("Switched to channel %s", req.Channel),
+		}
+
+		if err := s.supervisor.SetChannel(req.Channel); err != nil {
+			response = SetChannelResponse{
+				Success: false,
+				Message: err.Error(),
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
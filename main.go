@@ -53,6 +53,14 @@ func Run(config *config.Config, userMain func()) {
 	}
 
 	// We're the child - run user code with basic panic recovery
+	if config.ShutdownSignal != 0 {
+		shutdown.signal = config.ShutdownSignal
+	}
+
+	if config.ShutdownGracePeriod > 0 {
+		shutdown.gracePeriod = config.ShutdownGracePeriod
+	}
+
 	slog.Info("running as child", "pid", os.Getpid(), "socket", socketPath, "version", config.Version)
 
 	ipcClient, err = ipc.NewClient(socketPath)
@@ -70,13 +78,17 @@ func runAsChild(userMain func()) {
 	defer func() {
 		if r := recover(); r != nil {
 			slog.Error("Panic recovered: %v", r)
-			os.Exit(1) // Signal crash to supervisor
+			exit(1) // Signal crash to supervisor
 		}
 	}()
 
 	// Run user's actual code
 	userMain()
 
+	// Servers typically return as soon as they are asked to shut down, so
+	// let the shutdown callbacks finish draining
+	waitForShutdown()
+
 	// Clean exit
-	os.Exit(0)
+	exit(0)
 }
@@ -0,0 +1,70 @@
+package oras
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/zeitlos/knockknock/config"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+)
+
+// credentialStore builds the credential store used to authenticate against
+// the given registry. Credentials configured via config.AuthConfig take
+// precedence for that registry, everything else falls back to the Docker
+// credential store (~/.docker/config.json and its credential helpers).
+func credentialStore(auth *config.AuthConfig, registry string) (credentials.Store, error) {
+	docker, dockerErr := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+
+	if auth == nil {
+		if dockerErr != nil {
+			return nil, fmt.Errorf("failed to load docker credential store: %w", dockerErr)
+		}
+
+		return docker, nil
+	}
+
+	cred, err := credential(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	static := credentials.NewMemoryStore()
+
+	if err := static.Put(context.Background(), credentials.ServerAddressFromRegistry(registry), cred); err != nil {
+		return nil, fmt.Errorf("failed to store registry credentials: %w", err)
+	}
+
+	if dockerErr != nil {
+		// System users typically have no docker config at all, the configured
+		// credentials are all we need in that case.
+		slog.Warn("docker credential store unavailable, using configured credentials only", "error", dockerErr)
+
+		return static, nil
+	}
+
+	return credentials.NewStoreWithFallbacks(static, docker), nil
+}
+
+// credential maps the knockknock auth configuration to an ORAS credential.
+func credential(cfg *config.AuthConfig) (auth.Credential, error) {
+	hasBasic := cfg.Username != "" || cfg.Password != ""
+	hasToken := cfg.Token != "" || cfg.RefreshToken != ""
+
+	if hasBasic && hasToken {
+		return auth.EmptyCredential, fmt.Errorf("auth config must use either username/password or a token, not both")
+	}
+
+	if hasBasic && (cfg.Username == "" || cfg.Password == "") {
+		return auth.EmptyCredential, fmt.Errorf("auth config requires both username and password")
+	}
+
+	return auth.Credential{
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		AccessToken:  cfg.Token,
+		RefreshToken: cfg.RefreshToken,
+	}, nil
+}
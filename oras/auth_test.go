@@ -0,0 +1,241 @@
+package oras
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+// fakeRegistry is a minimal registry stand-in serving the tag list of one
+// repository to requests carrying the expected Authorization header.
+type fakeRegistry struct {
+	server *httptest.Server
+
+	// challenge is the WWW-Authenticate header sent to unauthorized requests,
+	// with %s replaced by the server URL
+	challenge string
+
+	// authorization is the Authorization header a request must carry
+	authorization string
+
+	// refreshToken is exchanged for accessToken at /token
+	refreshToken string
+	accessToken  string
+}
+
+func newFakeRegistry(t *testing.T, challenge, authorization string) *fakeRegistry {
+	t.Helper()
+
+	r := &fakeRegistry{challenge: challenge, authorization: authorization}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+
+	return r
+}
+
+func (r *fakeRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		r.serveToken(w, req)
+		return
+	}
+
+	if req.Header.Get("Authorization") != r.authorization {
+		w.Header().Set("WWW-Authenticate", strings.ReplaceAll(r.challenge, "%s", r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"name":"myapp","tags":["1.0.0","1.1.0","latest"]}`))
+}
+
+func (r *fakeRegistry) serveToken(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil || req.Method != http.MethodPost ||
+		req.PostForm.Get("grant_type") != "refresh_token" ||
+		req.PostForm.Get("refresh_token") != r.refreshToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"access_token": r.accessToken})
+}
+
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+func basic(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// emptyDockerConfig points the Docker credential store to an empty config,
+// so that the tests don't pick up the credentials of the host.
+func emptyDockerConfig(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	return dir
+}
+
+func listVersions(t *testing.T, cfg *config.Config) ([]string, error) {
+	t.Helper()
+
+	client, err := NewClient(cfg.WithVersion("1.0.0").WithTransport(&config.TransportConfig{PlainHTTP: true}))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	versions, err := client.Versions(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+
+	for _, v := range versions.Versions() {
+		tags = append(tags, v.Original())
+	}
+
+	return tags, nil
+}
+
+func TestAuth(t *testing.T) {
+	const bearerChallenge = `Bearer realm="%s/token",service="registry",scope="repository:myapp:pull"`
+
+	tests := []struct {
+		name     string
+		registry func(t *testing.T) *fakeRegistry
+		auth     func(r *fakeRegistry) *config.AuthConfig
+		wantErr  bool
+	}{
+		{
+			name: "basic auth",
+			registry: func(t *testing.T) *fakeRegistry {
+				return newFakeRegistry(t, `Basic realm="registry"`, basic("user", "secret"))
+			},
+			auth: func(*fakeRegistry) *config.AuthConfig {
+				return &config.AuthConfig{Username: "user", Password: "secret"}
+			},
+		},
+		{
+			name: "wrong password",
+			registry: func(t *testing.T) *fakeRegistry {
+				return newFakeRegistry(t, `Basic realm="registry"`, basic("user", "secret"))
+			},
+			auth: func(*fakeRegistry) *config.AuthConfig {
+				return &config.AuthConfig{Username: "user", Password: "wrong"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "bearer token",
+			registry: func(t *testing.T) *fakeRegistry {
+				return newFakeRegistry(t, bearerChallenge, "Bearer static-token")
+			},
+			auth: func(*fakeRegistry) *config.AuthConfig {
+				return &config.AuthConfig{Token: "static-token"}
+			},
+		},
+		{
+			name: "refresh token exchange",
+			registry: func(t *testing.T) *fakeRegistry {
+				r := newFakeRegistry(t, bearerChallenge, "Bearer exchanged-token")
+				r.refreshToken = "identity-token"
+				r.accessToken = "exchanged-token"
+
+				return r
+			},
+			auth: func(*fakeRegistry) *config.AuthConfig {
+				return &config.AuthConfig{RefreshToken: "identity-token"}
+			},
+		},
+		{
+			name: "no credentials",
+			registry: func(t *testing.T) *fakeRegistry {
+				return newFakeRegistry(t, `Basic realm="registry"`, basic("user", "secret"))
+			},
+			auth:    func(*fakeRegistry) *config.AuthConfig { return nil },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emptyDockerConfig(t)
+
+			r := tt.registry(t)
+			cfg := config.New("myapp").WithRepo(r.host() + "/myapp").WithAuth(tt.auth(r))
+
+			tags, err := listVersions(t, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Versions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && strings.Join(tags, ",") != "1.0.0,1.1.0" {
+				t.Errorf("Versions() = %v, want [1.0.0 1.1.0]", tags)
+			}
+		})
+	}
+}
+
+func TestAuthDockerFallback(t *testing.T) {
+	dir := emptyDockerConfig(t)
+
+	r := newFakeRegistry(t, `Basic realm="registry"`, basic("docker-user", "docker-secret"))
+
+	dockerConfig := fmt.Sprintf(`{"auths":{%q:{"auth":%q}}}`, r.host(),
+		base64.StdEncoding.EncodeToString([]byte("docker-user:docker-secret")))
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(dockerConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("without auth config", func(t *testing.T) {
+		if _, err := listVersions(t, config.New("myapp").WithRepo(r.host()+"/myapp")); err != nil {
+			t.Fatalf("Versions() error = %v", err)
+		}
+	})
+
+	t.Run("auth config takes precedence", func(t *testing.T) {
+		cfg := config.New("myapp").WithRepo(r.host() + "/myapp").
+			WithAuth(&config.AuthConfig{Username: "user", Password: "wrong"})
+
+		if _, err := listVersions(t, cfg); err == nil {
+			t.Fatal("Versions() succeeded with the docker credentials, want the configured ones to be used")
+		}
+	})
+}
+
+func TestCredentialValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    config.AuthConfig
+		wantErr bool
+	}{
+		{name: "basic", auth: config.AuthConfig{Username: "user", Password: "secret"}},
+		{name: "token", auth: config.AuthConfig{Token: "token"}},
+		{name: "refresh token", auth: config.AuthConfig{RefreshToken: "token"}},
+		{name: "username only", auth: config.AuthConfig{Username: "user"}, wantErr: true},
+		{name: "password only", auth: config.AuthConfig{Password: "secret"}, wantErr: true},
+		{name: "basic and token", auth: config.AuthConfig{Username: "user", Password: "secret", Token: "token"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := credential(&tt.auth); (err != nil) != tt.wantErr {
+				t.Errorf("credential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package oras
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+
+	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// NewLayoutClient returns a client reading releases from an OCI image
+// layout, either a directory or an oci-layout tarball.
+func NewLayoutClient(ctx context.Context, config *config.Config, path string) (*Client, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image layout: %w", err)
+	}
+
+	var store *oci.ReadOnlyStore
+
+	if info.IsDir() {
+		store, err = oci.NewFromFS(ctx, os.DirFS(path))
+	} else {
+		store, err = oci.NewFromTar(ctx, path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image layout %s: %w", path, err)
+	}
+
+	return newClient(config, store, path)
+}
+
+// LayoutSource reads releases from OCI image layouts in a directory, for
+// hosts without registry access. The directory is either an image layout
+// itself, or holds image layout directories and oci-layout tarballs
+// (*.tar). It is rescanned whenever versions are listed, so releases
+// dropped into it are picked up by the next update check.
+type LayoutSource struct {
+	config *config.Config
+	dir    string
+
+	mu      sync.Mutex
+	layouts map[string]*layout
+}
+
+// layout is an opened image layout, or the error opening it, for the
+// modification time it was opened at.
+type layout struct {
+	client  *Client
+	err     error
+	modTime time.Time
+}
+
+// NewLayoutSource returns a source reading the image layouts in dir.
+func NewLayoutSource(config *config.Config, dir string) (*LayoutSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid layout directory: %s is not a directory", dir)
+	}
+
+	return &LayoutSource{
+		config:  config,
+		dir:     dir,
+		layouts: map[string]*layout{},
+	}, nil
+}
+
+// Versions lists the semver tags of all image layouts.
+func (l *LayoutSource) Versions(ctx context.Context) (release.VersionSet, error) {
+	clients, err := l.scan(ctx)
+	if err != nil {
+		return release.VersionSet{}, err
+	}
+
+	var versions []semver.Version
+
+	for _, client := range clients {
+		set, err := client.Versions(ctx)
+		if err != nil {
+			return release.VersionSet{}, err
+		}
+
+		versions = append(versions, set.Versions()...)
+	}
+
+	return release.NewVersionSet(versions...), nil
+}
+
+// Resolve resolves a tag or digest in the first image layout, in path
+// order, that contains it.
+func (l *LayoutSource) Resolve(ctx context.Context, reference string) (*release.Artifact, error) {
+	clients, err := l.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, client := range clients {
+		if _, err := client.resolve(ctx, reference); err != nil {
+			continue
+		}
+
+		artifact, err := client.Resolve(ctx, reference)
+		if err != nil {
+			return nil, err
+		}
+
+		locate(artifact.Files, client.name)
+
+		return artifact, nil
+	}
+
+	return nil, fmt.Errorf("failed to resolve %s: not found in %s", reference, l.dir)
+}
+
+// Fetch opens a blob of the image layout the file was resolved from.
+func (l *LayoutSource) Fetch(ctx context.Context, file release.File) (io.ReadCloser, error) {
+	client, err := l.client(file.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Fetch(ctx, file)
+}
+
+// Signatures returns the detached signatures in the artifact's image layout.
+func (l *LayoutSource) Signatures(ctx context.Context, artifact *release.Artifact) ([][]byte, error) {
+	client, err := l.client(layoutPath(artifact))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Signatures(ctx, artifact)
+}
+
+// Patches returns the binary patches in the artifact's image layout.
+func (l *LayoutSource) Patches(ctx context.Context, artifact *release.Artifact) ([]release.Patch, error) {
+	client, err := l.client(layoutPath(artifact))
+	if err != nil {
+		return nil, err
+	}
+
+	patches, err := client.Patches(ctx, artifact)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, patch := range patches {
+		locate(patch.Files, client.name)
+	}
+
+	return patches, nil
+}
+
+// scan opens new and changed image layouts in the directory and returns
+// all usable ones in path order. Layouts that fail to open, e.g. tarballs
+// still being copied, are retried once they change.
+func (l *LayoutSource) scan(ctx context.Context) ([]*Client, error) {
+	paths, err := l.layoutPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var clients []*Client
+
+	found := map[string]bool{}
+
+	for _, path := range paths {
+		found[path] = true
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			// Tags are added to index.json without touching the directory
+			if index, err := os.Stat(filepath.Join(path, ocispec.ImageIndexFile)); err == nil {
+				info = index
+			}
+		}
+
+		cached, ok := l.layouts[path]
+
+		if !ok || !cached.modTime.Equal(info.ModTime()) {
+			client, err := NewLayoutClient(ctx, l.config, path)
+			if err != nil {
+				slog.Warn("skipping image layout", "path", path, "error", err)
+			}
+
+			cached = &layout{client: client, err: err, modTime: info.ModTime()}
+			l.layouts[path] = cached
+		}
+
+		if cached.err == nil {
+			clients = append(clients, cached.client)
+		}
+	}
+
+	for path := range l.layouts {
+		if !found[path] {
+			delete(l.layouts, path)
+		}
+	}
+
+	return clients, nil
+}
+
+// layoutPaths lists the image layouts in the directory: the directory
+// itself if it is one, else its image layout subdirectories and tarballs.
+func (l *LayoutSource) layoutPaths() ([]string, error) {
+	if isLayoutDir(l.dir) {
+		return []string{l.dir}, nil
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list layout directory: %w", err)
+	}
+
+	var paths []string
+
+	for _, entry := range entries {
+		path := filepath.Join(l.dir, entry.Name())
+
+		switch {
+		case entry.IsDir() && isLayoutDir(path):
+			paths = append(paths, path)
+		case entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), ".tar"):
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// client returns the opened image layout at path.
+func (l *LayoutSource) client(path string) (*Client, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cached, ok := l.layouts[path]
+	if !ok || cached.err != nil {
+		return nil, fmt.Errorf("image layout %s is not available", path)
+	}
+
+	return cached.client, nil
+}
+
+func isLayoutDir(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ocispec.ImageLayoutFile))
+	return err == nil
+}
+
+// locate records the image layout files are fetched from.
+func locate(files []release.File, path string) {
+	for i := range files {
+		files[i].Location = path
+	}
+}
+
+// layoutPath returns the image layout an artifact was resolved from, which
+// qualifies its reference.
+func layoutPath(artifact *release.Artifact) string {
+	return artifact.Reference[:max(strings.LastIndex(artifact.Reference, "@"), 0)]
+}
@@ -0,0 +1,324 @@
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+const (
+	// mirrorBackoff is how long a failed repository is skipped, doubling
+	// with every consecutive failure up to maxMirrorBackoff
+	mirrorBackoff    = 30 * time.Second
+	maxMirrorBackoff = 10 * time.Minute
+)
+
+// MirrorSource spreads requests over the configured repository and its
+// mirrors. Repositories are tried in order, skipping those that recently
+// failed, so requests fail over to the mirrors while the repository is
+// unavailable and return to it once it recovered. Versions are listed from
+// the first repository that answers.
+//
+// Tags are only resolved by the repository, as a mirror could map them to
+// any digest. Artifacts are pinned to the digest their version resolves
+// to, and manifests and files are verified against it wherever they are
+// fetched from.
+type MirrorSource struct {
+	mirrors []*mirror
+
+	mu sync.Mutex
+}
+
+// mirror is a repository and its health.
+type mirror struct {
+	client *Client
+
+	failures  int
+	lastError error
+	retryAt   time.Time
+}
+
+// MirrorStatus describes the health of a repository.
+type MirrorStatus struct {
+	Repo    string
+	Healthy bool
+
+	// Failures is the number of consecutive failed requests
+	Failures  int
+	LastError string
+
+	// RetryAt is when an unhealthy repository is tried again
+	RetryAt time.Time
+}
+
+// NewMirrorSource returns a source for the configured repository and its
+// mirrors.
+func NewMirrorSource(config *config.Config) (*MirrorSource, error) {
+	primary, err := NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &MirrorSource{
+		mirrors: []*mirror{{client: primary}},
+	}
+
+	for _, m := range config.Mirrors {
+		transport := m.Transport
+		if transport == nil {
+			transport = config.Transport
+		}
+
+		client, err := newRepositoryClient(config, m.Repo, m.Auth, transport)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirror: %w", err)
+		}
+
+		source.mirrors = append(source.mirrors, &mirror{client: client})
+	}
+
+	return source, nil
+}
+
+// Versions lists the versions of the first repository that answers.
+func (m *MirrorSource) Versions(ctx context.Context) (release.VersionSet, error) {
+	var versions release.VersionSet
+
+	err := m.try(ctx, func(client *Client) (err error) {
+		versions, err = client.Versions(ctx)
+		return err
+	})
+
+	return versions, err
+}
+
+// Resolve resolves tags in the repository, so releases can't be resolved
+// while it is unavailable. Digests are resolved in the first repository
+// that has them, as their manifests are verified against the digest.
+func (m *MirrorSource) Resolve(ctx context.Context, reference string) (*release.Artifact, error) {
+	mirrors := m.mirrors[:1]
+
+	if _, err := digest.Parse(reference); err == nil {
+		mirrors = m.ordered()
+	}
+
+	var artifact *release.Artifact
+
+	err := m.tryEach(ctx, mirrors, func(client *Client) (err error) {
+		artifact, err = client.Resolve(ctx, reference)
+		return err
+	})
+
+	return artifact, err
+}
+
+// Fetch opens a blob from the first repository that serves it. Its digest
+// is verified once it is downloaded.
+func (m *MirrorSource) Fetch(ctx context.Context, file release.File) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+
+	err := m.try(ctx, func(client *Client) (err error) {
+		rc, err = client.Fetch(ctx, file)
+		return err
+	})
+
+	return rc, err
+}
+
+// Signatures returns the detached signatures from the first repository that
+// has any, as mirrors might not copy referrers.
+func (m *MirrorSource) Signatures(ctx context.Context, artifact *release.Artifact) ([][]byte, error) {
+	var signatures [][]byte
+
+	err := m.try(ctx, func(client *Client) (err error) {
+		signatures, err = client.Signatures(ctx, artifact)
+		if err == nil && len(signatures) == 0 {
+			return errdef.ErrNotFound
+		}
+
+		return err
+	})
+
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil, nil
+	}
+
+	return signatures, err
+}
+
+// Patches returns the binary patches from the first repository that has
+// any.
+func (m *MirrorSource) Patches(ctx context.Context, artifact *release.Artifact) ([]release.Patch, error) {
+	var patches []release.Patch
+
+	err := m.try(ctx, func(client *Client) (err error) {
+		patches, err = client.Patches(ctx, artifact)
+		if err == nil && len(patches) == 0 {
+			return errdef.ErrNotFound
+		}
+
+		return err
+	})
+
+	if errors.Is(err, errdef.ErrNotFound) {
+		return nil, nil
+	}
+
+	return patches, err
+}
+
+// Status returns the health of the repository and its mirrors, in the
+// configured order.
+func (m *MirrorSource) Status() []MirrorStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	statuses := make([]MirrorStatus, 0, len(m.mirrors))
+
+	for _, mirror := range m.mirrors {
+		status := MirrorStatus{
+			Repo:     mirror.client.name,
+			Healthy:  !now.Before(mirror.retryAt),
+			Failures: mirror.failures,
+			RetryAt:  mirror.retryAt,
+		}
+
+		if mirror.lastError != nil {
+			status.LastError = mirror.lastError.Error()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// try runs fn against the repositories in order until it succeeds. Healthy
+// repositories are tried first, then the unhealthy ones in the order they
+// are due to be retried, so that requests still go out while all of them
+// are failing.
+func (m *MirrorSource) try(ctx context.Context, fn func(*Client) error) error {
+	return m.tryEach(ctx, m.ordered(), fn)
+}
+
+// tryEach runs fn against the given repositories in order until it
+// succeeds, recording their health.
+func (m *MirrorSource) tryEach(ctx context.Context, mirrors []*mirror, fn func(*Client) error) error {
+	var errs []error
+
+	for _, mirror := range mirrors {
+		err := fn(mirror.client)
+
+		if err == nil {
+			m.succeeded(mirror)
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if isOutage(err) {
+			m.failed(mirror, err)
+		}
+
+		errs = append(errs, err)
+	}
+
+	// Not found everywhere is reported as such, rather than as the first
+	// of many errors
+	if len(errs) > 0 && allNotFound(errs) {
+		return fmt.Errorf("%w: %w", errdef.ErrNotFound, errors.Join(errs...))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *MirrorSource) ordered() []*mirror {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	ordered := make([]*mirror, len(m.mirrors))
+	copy(ordered, m.mirrors)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		iHealthy, jHealthy := !now.Before(ordered[i].retryAt), !now.Before(ordered[j].retryAt)
+
+		if iHealthy != jHealthy {
+			return iHealthy
+		}
+
+		return !iHealthy && ordered[i].retryAt.Before(ordered[j].retryAt)
+	})
+
+	return ordered
+}
+
+func (m *MirrorSource) succeeded(mirror *mirror) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mirror.failures > 0 {
+		slog.Info("repository recovered", "repo", mirror.client.name)
+	}
+
+	mirror.failures = 0
+	mirror.lastError = nil
+	mirror.retryAt = time.Time{}
+}
+
+func (m *MirrorSource) failed(mirror *mirror, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mirror.failures++
+	mirror.lastError = err
+
+	backoff := mirrorBackoff << min(mirror.failures-1, 10)
+	mirror.retryAt = time.Now().Add(min(backoff, maxMirrorBackoff))
+
+	slog.Warn("repository unavailable, failing over", "repo", mirror.client.name, "failures", mirror.failures, "retry_at", mirror.retryAt, "error", err)
+}
+
+// isOutage reports whether err means the repository is unavailable, as
+// opposed to it not having the requested content.
+func isOutage(err error) bool {
+	if errors.Is(err, errdef.ErrNotFound) {
+		return false
+	}
+
+	var response *errcode.ErrorResponse
+
+	if errors.As(err, &response) {
+		return response.StatusCode != http.StatusNotFound
+	}
+
+	return true
+}
+
+func allNotFound(errs []error) bool {
+	for _, err := range errs {
+		if isOutage(err) {
+			return false
+		}
+	}
+
+	return true
+}
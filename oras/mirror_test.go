@@ -0,0 +1,73 @@
+package oras
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// unavailable is a repository that fails every request.
+type unavailable struct {
+	target
+}
+
+var errUnavailable = errors.New("service unavailable")
+
+func (unavailable) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, errUnavailable
+}
+
+func (unavailable) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return nil, errUnavailable
+}
+
+func (unavailable) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	return errUnavailable
+}
+
+func TestMirrorsDoNotResolveTags(t *testing.T) {
+	ctx := context.Background()
+
+	primary := newLayoutClient(t, "1.2.0")
+	copied := newLayoutClient(t, "1.2.0")
+
+	pinned, err := primary.Resolve(ctx, "1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary.oras = unavailable{primary.oras}
+
+	source := &MirrorSource{mirrors: []*mirror{{client: primary}, {client: copied}}}
+
+	if _, err := source.Resolve(ctx, "1.2.0"); !errors.Is(err, errUnavailable) {
+		t.Errorf("Resolve(tag) error = %v, want the repository's error", err)
+	}
+
+	if _, err := source.Versions(ctx); err != nil {
+		t.Errorf("Versions() error = %v, want versions from the mirror", err)
+	}
+
+	artifact, err := source.Resolve(ctx, pinned.Digest.String())
+	if err != nil {
+		t.Fatalf("Resolve(digest) error = %v", err)
+	}
+
+	if artifact.Digest != pinned.Digest {
+		t.Errorf("Resolve(digest) = %s, want %s", artifact.Digest, pinned.Digest)
+	}
+
+	rc, err := source.Fetch(ctx, artifact.Files[0])
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	rc.Close()
+
+	if status := source.Status(); status[0].Healthy || status[0].Failures == 0 {
+		t.Errorf("Status() = %+v, want the repository marked unhealthy", status[0])
+	}
+}
@@ -0,0 +1,59 @@
+package oras
+
+import (
+	"context"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/zeitlos/knockknock/release"
+)
+
+const (
+	// PatchArtifactType is the artifact type of binary patches attached to a
+	// release manifest as OCI referrers. Each layer is a bsdiff 4 patch
+	// titled with the path of the file it produces.
+	PatchArtifactType = "application/vnd.knockknock.patch.v1"
+)
+
+// Patches returns the binary patches published for the given artifact.
+func (r *Client) Patches(ctx context.Context, artifact *release.Artifact) ([]release.Patch, error) {
+	referrers, err := r.referrers(ctx, subjects(artifact)[0], PatchArtifactType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list patch referrers: %w", err)
+	}
+
+	var patches []release.Patch
+
+	for _, referrer := range referrers {
+		manifest, err := r.fetchManifest(ctx, referrer)
+		if err != nil {
+			return nil, err
+		}
+
+		patch := release.Patch{
+			Base: manifest.Annotations[release.PatchBaseAnnotation],
+		}
+
+		if patch.Base == "" {
+			continue
+		}
+
+		for _, layer := range manifest.Layers {
+			title := layer.Annotations[ocispec.AnnotationTitle]
+
+			if title == "" {
+				continue
+			}
+
+			patch.Files = append(patch.Files, release.File{
+				Path:   title,
+				Digest: layer.Digest,
+				Size:   layer.Size,
+			})
+		}
+
+		patches = append(patches, patch)
+	}
+
+	return patches, nil
+}
@@ -0,0 +1,113 @@
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// mediaTypeDockerManifestList is the Docker equivalent of an OCI image index.
+const mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+func isIndex(mediaType string) bool {
+	return mediaType == ocispec.MediaTypeImageIndex || mediaType == mediaTypeDockerManifestList
+}
+
+// selectPlatform picks the manifest matching the running platform from an
+// image index. Manifests with a matching variant are preferred over
+// manifests without a variant.
+func (r *Client) selectPlatform(ctx context.Context, desc ocispec.Descriptor) (ocispec.Descriptor, *ocispec.Index, error) {
+	data, err := content.FetchAll(ctx, r.oras, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to fetch index %s: %w", desc.Digest, err)
+	}
+
+	var index ocispec.Index
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to decode index %s: %w", desc.Digest, err)
+	}
+
+	variant := platformVariant()
+
+	var match *ocispec.Descriptor
+
+	for _, manifest := range index.Manifests {
+		p := manifest.Platform
+
+		if p == nil || p.OS != runtime.GOOS || p.Architecture != runtime.GOARCH {
+			continue
+		}
+
+		if p.Variant == variant {
+			return manifest, &index, nil
+		}
+
+		if p.Variant == "" && match == nil {
+			match = &manifest
+		}
+	}
+
+	if match == nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("index %s has no manifest for platform %s", desc.Digest, Platform())
+	}
+
+	return *match, &index, nil
+}
+
+// Platform returns the running platform as os/arch[/variant].
+func Platform() string {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	if variant := platformVariant(); variant != "" {
+		platform += "/" + variant
+	}
+
+	return platform
+}
+
+// platformVariant returns the OCI platform variant of the running binary,
+// e.g. "v7" for GOARM=7 or "v8" on arm64.
+func platformVariant() string {
+	switch runtime.GOARCH {
+	case "arm64":
+		return "v8"
+	case "arm":
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "GOARM" {
+					// e.g. "7" or "7,softfloat"
+					version, _, _ := strings.Cut(setting.Value, ",")
+					return "v" + version
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// mergeAnnotations combines index and manifest annotations, preferring the
+// manifest's.
+func mergeAnnotations(index *ocispec.Index, manifest *ocispec.Manifest) map[string]string {
+	if index == nil {
+		return manifest.Annotations
+	}
+
+	annotations := maps.Clone(index.Annotations)
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	maps.Copy(annotations, manifest.Annotations)
+
+	return annotations
+}
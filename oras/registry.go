@@ -2,47 +2,84 @@ package oras
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 
 	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
 
 	"github.com/Masterminds/semver/v3"
-	"oras.land/oras-go/v2"
-	"oras.land/oras-go/v2/content/file"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/credentials"
-	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
+// target is the content releases are read from: a remote repository or an
+// OCI image layout.
+type target interface {
+	content.ReadOnlyGraphStorage
+	content.Resolver
+	registry.TagLister
+}
+
 type Client struct {
-	oras           *remote.Repository
+	oras target
+
+	// name qualifies artifact references, the repository or the path of
+	// the image layout
+	name string
+
 	currentVersion *semver.Version
 
 	config *config.Config
 }
 
+// unpackAnnotation marks directory layers pushed by the oras CLI as tarballs
+// to be extracted.
+const unpackAnnotation = "io.deis.oras.content.unpack"
+
 func NewClient(config *config.Config) (*Client, error) {
-	repo, err := remote.NewRepository(config.Repo)
+	return newRepositoryClient(config, config.Repo, config.Auth, config.Transport)
+}
+
+// newRepositoryClient returns a client for the given repository, which is
+// the configured one or one of its mirrors.
+func newRepositoryClient(config *config.Config, repository string, authConfig *config.AuthConfig, transport *config.TransportConfig) (*Client, error) {
+	repo, err := remote.NewRepository(repository)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository %s: %w", repository, err)
+	}
+
+	store, err := credentialStore(authConfig, repo.Reference.Registry)
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid repository: %w", err)
+		return nil, err
 	}
 
-	store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	httpClient, err := NewHTTPClient(transport)
 
 	if err != nil {
 		return nil, err
 	}
 
 	repo.Client = &auth.Client{
-		Client:     retry.DefaultClient,
+		Client:     httpClient,
 		Cache:      auth.NewCache(),
 		Credential: credentials.Credential(store),
 	}
 
+	repo.PlainHTTP = transport != nil && transport.PlainHTTP
+
+	return newClient(config, repo, repo.Reference.Registry+"/"+repo.Reference.Repository)
+}
+
+func newClient(config *config.Config, target target, name string) (*Client, error) {
 	currentVersion, err := semver.NewVersion(config.Version)
 
 	if err != nil {
@@ -50,14 +87,16 @@ func NewClient(config *config.Config) (*Client, error) {
 	}
 
 	return &Client{
-		oras:           repo,
+		oras:           target,
+		name:           name,
 		currentVersion: currentVersion,
 
 		config: config,
 	}, nil
 }
 
-func (r *Client) Versions(ctx context.Context) ([]semver.Version, error) {
+// Versions lists all semver tags of the repository as an ordered set.
+func (r *Client) Versions(ctx context.Context) (release.VersionSet, error) {
 	var tags []string
 
 	err := r.oras.Tags(ctx, "", func(tagsPage []string) error {
@@ -67,83 +106,166 @@ func (r *Client) Versions(ctx context.Context) ([]semver.Version, error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tags: %w", err)
-	}
-
-	var versions []semver.Version
-	for _, tag := range tags {
-		v, err := semver.NewVersion(tag)
-
-		if err != nil {
-			// Skip non-semver tags
-			continue
-		}
-
-		versions = append(versions, *v)
+		return release.VersionSet{}, fmt.Errorf("failed to list tags: %w", err)
 	}
 
-	return versions, nil
+	return release.ParseTags(tags), nil
 }
 
 func (r *Client) CheckForUpdate(ctx context.Context) (update *semver.Version, allVersions []semver.Version, err error) {
-	allVersions, err = r.Versions(ctx)
+	versions, err := r.Versions(ctx)
 
 	if err != nil {
 		return
 	}
 
-	if len(allVersions) == 0 {
+	if versions.Len() == 0 {
 		err = fmt.Errorf("no versions found in repository")
 		return
 	}
 
-	latest := allVersions[len(allVersions)-1]
+	allVersions = versions.Versions()
 
-	if latest.GreaterThan(r.currentVersion) {
+	if latest := versions.Latest(); latest.GreaterThan(r.currentVersion) {
 		// Update available
-		update = &latest
-		return
+		update = latest
 	}
 
-	// No update available
 	return
 }
 
-func (r *Client) DownloadUpdate(ctx context.Context, version, destDir string) error {
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("failed to create destination dir: %w", err)
+// Resolve resolves a tag or digest to its manifest, pinning all following
+// operations on the returned artifact to that exact digest. For multi-arch
+// releases published as an image index, the manifest matching the running
+// platform is selected; index annotations are inherited by the artifact.
+func (r *Client) Resolve(ctx context.Context, reference string) (*release.Artifact, error) {
+	desc, err := r.resolve(ctx, reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", reference, err)
+	}
+
+	var (
+		indexDesc *ocispec.Descriptor
+		index     *ocispec.Index
+	)
+
+	if isIndex(desc.MediaType) {
+		indexDesc = &desc
+
+		desc, index, err = r.selectPlatform(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	fs, err := file.New(destDir)
+	manifest, err := r.fetchManifest(ctx, desc)
 	if err != nil {
-		return fmt.Errorf("failed to create fs store: %w", err)
+		return nil, err
 	}
-	defer fs.Close()
 
-	if _, err := oras.Copy(ctx, r.oras, version, fs, version, oras.DefaultCopyOptions); err != nil {
-		return fmt.Errorf("failed to download version %s: %w", version, err)
+	artifact := &release.Artifact{
+		Reference:   fmt.Sprintf("%s@%s", r.name, desc.Digest),
+		Digest:      desc.Digest,
+		Annotations: mergeAnnotations(index, manifest),
 	}
 
-	entries, err := os.ReadDir(destDir)
-	if err != nil {
-		return fmt.Errorf("failed to read destination directory: %w", err)
+	if indexDesc != nil {
+		artifact.Index = indexDesc.Digest
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ocispec.AnnotationTitle]
 
-		if entry.Name() != r.config.BinaryName {
+		if title == "" {
 			continue
 		}
 
-		binaryPath := filepath.Join(destDir, entry.Name())
+		if layer.Annotations[unpackAnnotation] == "true" {
+			return nil, fmt.Errorf("layer %s is a directory, push its files individually", title)
+		}
 
-		if err := os.Chmod(binaryPath, 0755); err != nil {
-			return fmt.Errorf("failed to chmod %s: %w", entry.Name(), err)
+		file, err := release.NewFile(title, layer.Annotations, r.config.BinaryName)
+		if err != nil {
+			return nil, err
 		}
+
+		file.Digest = layer.Digest
+		file.Size = layer.Size
+
+		artifact.Files = append(artifact.Files, file)
+	}
+
+	return artifact, nil
+}
+
+// resolve resolves a reference to its descriptor. Versions are passed
+// around in their canonical spelling ("1.2.3") while the tag might differ
+// ("v1.2.3"), so versions that aren't tagged as spelled are looked up in
+// the tag list.
+func (r *Client) resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, err := r.oras.Resolve(ctx, reference)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		return desc, err
+	}
+
+	if _, err := semver.NewVersion(reference); err != nil {
+		return desc, err
+	}
+
+	versions, listErr := r.Versions(ctx)
+	if listErr != nil {
+		return desc, err
+	}
+
+	tag := versions.Tag(reference)
+	if tag == reference {
+		return desc, err
+	}
+
+	return r.oras.Resolve(ctx, tag)
+}
+
+// Fetch opens a blob of the repository. For registries supporting range
+// requests and image layouts the reader is seekable.
+func (r *Client) Fetch(ctx context.Context, file release.File) (io.ReadCloser, error) {
+	rc, err := r.oras.Fetch(ctx, ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayer,
+		Digest:    file.Digest,
+		Size:      file.Size,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", file.Path, err)
+	}
+
+	return rc, nil
+}
+
+// DownloadUpdate downloads the files of the resolved artifact into destDir.
+func (r *Client) DownloadUpdate(ctx context.Context, artifact *release.Artifact, destDir string, opts release.DownloadOptions) error {
+	return release.Download(ctx, r, artifact, destDir, opts)
+}
+
+// referrers lists the manifests of the given artifact type referring to
+// subject.
+func (r *Client) referrers(ctx context.Context, subject ocispec.Descriptor, artifactType string) ([]ocispec.Descriptor, error) {
+	return registry.Referrers(ctx, r.oras, subject, artifactType)
+}
+
+// subjects returns the descriptors referrers of the artifact may be attached
+// to: the platform manifest and, for multi-arch releases, the index.
+func subjects(artifact *release.Artifact) []ocispec.Descriptor {
+	subjects := []ocispec.Descriptor{{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    artifact.Digest,
+	}}
+
+	if artifact.Index != "" {
+		subjects = append(subjects, ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageIndex,
+			Digest:    artifact.Index,
+		})
 	}
 
-	return nil
+	return subjects
 }
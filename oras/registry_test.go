@@ -0,0 +1,122 @@
+package oras
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zeitlos/knockknock/config"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// newLayoutClient returns a client for an image layout holding a release of
+// myapp under each of the given tags.
+func newLayoutClient(t *testing.T, tags ...string) *Client {
+	t.Helper()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := oci.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tag := range tags {
+		layer, err := oras.PushBytes(ctx, store, "application/octet-stream", []byte("myapp "+tag))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: "myapp"}
+
+		manifest, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.knockknock.release", oras.PackManifestOptions{
+			Layers:              []ocispec.Descriptor{layer},
+			ManifestAnnotations: map[string]string{ocispec.AnnotationVersion: tag},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.Tag(ctx, manifest, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client, err := newClient(config.New("myapp").WithVersion("1.0.0"), store, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client
+}
+
+func TestResolveVersionSpelling(t *testing.T) {
+	client := newLayoutClient(t, "v1.10.0", "1.11.0", "latest")
+
+	tests := []struct {
+		reference string
+		wantTag   string
+		wantErr   bool
+	}{
+		{reference: "1.10.0", wantTag: "v1.10.0"},
+		{reference: "v1.10.0", wantTag: "v1.10.0"},
+		{reference: "v1.11.0", wantTag: "1.11.0"},
+		{reference: "1.11", wantTag: "1.11.0"},
+		{reference: "latest", wantTag: "latest"},
+		{reference: "1.12.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			artifact, err := client.Resolve(context.Background(), tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve(%q) error = %v, wantErr %v", tt.reference, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got := artifact.Annotations[ocispec.AnnotationVersion]; got != tt.wantTag {
+				t.Errorf("Resolve(%q) resolved tag %s, want %s", tt.reference, got, tt.wantTag)
+			}
+		})
+	}
+}
+
+// listingTarget counts how often the tags are listed.
+type listingTarget struct {
+	target
+	listings int
+}
+
+func (l *listingTarget) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	l.listings++
+	return l.target.Tags(ctx, last, fn)
+}
+
+func TestResolveListsTagsOnlyForOtherSpellings(t *testing.T) {
+	client := newLayoutClient(t, "v1.10.0", "1.11.0")
+
+	listing := &listingTarget{target: client.oras}
+	client.oras = listing
+
+	if _, err := client.Resolve(context.Background(), "1.11.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if listing.listings != 0 {
+		t.Errorf("resolving a tag as spelled listed tags %d times, want none", listing.listings)
+	}
+
+	if _, err := client.Resolve(context.Background(), "1.10.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if listing.listings != 1 {
+		t.Errorf("resolving another spelling listed tags %d times, want once", listing.listings)
+	}
+}
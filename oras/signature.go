@@ -0,0 +1,76 @@
+package oras
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"oras.land/oras-go/v2/content"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/zeitlos/knockknock/release"
+)
+
+const (
+	// SignatureArtifactType is the artifact type of detached signatures
+	// attached to a release manifest as OCI referrers.
+	SignatureArtifactType = "application/vnd.knockknock.signature.v1"
+
+	// maxSignatureSize guards against referrers pointing to arbitrarily
+	// large blobs.
+	maxSignatureSize = 64 * 1024
+)
+
+// Signatures returns the detached signatures attached to the given artifact
+// as signature referrers.
+func (r *Client) Signatures(ctx context.Context, artifact *release.Artifact) ([][]byte, error) {
+	var signatures [][]byte
+
+	var referrers []ocispec.Descriptor
+
+	for _, subject := range subjects(artifact) {
+		page, err := r.referrers(ctx, subject, SignatureArtifactType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list signature referrers: %w", err)
+		}
+
+		referrers = append(referrers, page...)
+	}
+
+	for _, referrer := range referrers {
+		signatureManifest, err := r.fetchManifest(ctx, referrer)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, layer := range signatureManifest.Layers {
+			if layer.Size > maxSignatureSize {
+				continue
+			}
+
+			signature, err := content.FetchAll(ctx, r.oras, layer)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch signature %s: %w", layer.Digest, err)
+			}
+
+			signatures = append(signatures, signature)
+		}
+	}
+
+	return signatures, nil
+}
+
+func (r *Client) fetchManifest(ctx context.Context, desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	data, err := content.FetchAll(ctx, r.oras, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest %s: %w", desc.Digest, err)
+	}
+
+	var manifest ocispec.Manifest
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", desc.Digest, err)
+	}
+
+	return &manifest, nil
+}
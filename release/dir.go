@@ -0,0 +1,225 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// DirSource reads releases from a local directory with one subdirectory per
+// version, e.g. on a mounted share or an air-gapped host:
+//
+//	releases/
+//	  1.2.3/my-binary
+//	  1.2.4/my-binary
+//	  1.2.4/config/defaults.yaml
+//
+// File modes are taken from the files on disk. Artifacts are pinned by the
+// digest of their file listing, so changing any file changes the digest.
+// File digests are cached until the file's size or modification time
+// changes; downloads verify the content against them either way.
+type DirSource struct {
+	dir string
+
+	mu      sync.Mutex
+	digests map[string]cachedDigest
+}
+
+// cachedDigest is the digest of a file as of its size and modification
+// time.
+type cachedDigest struct {
+	digest  digest.Digest
+	size    int64
+	modTime time.Time
+}
+
+// NewDirSource returns a source reading the versions in dir.
+func NewDirSource(dir string) (*DirSource, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid release directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("invalid release directory: %s is not a directory", dir)
+	}
+
+	return &DirSource{dir: dir, digests: map[string]cachedDigest{}}, nil
+}
+
+// Versions lists the subdirectories named like a version.
+func (d *DirSource) Versions(ctx context.Context) (VersionSet, error) {
+	names, err := d.versionDirs()
+	if err != nil {
+		return VersionSet{}, err
+	}
+
+	return ParseTags(names), nil
+}
+
+// Resolve returns the files of a version directory, by version or by the
+// digest of its file listing. Resolving by digest hashes every version.
+func (d *DirSource) Resolve(ctx context.Context, reference string) (*Artifact, error) {
+	names, err := d.versionDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	byDigest := strings.Contains(reference, ":")
+
+	for _, name := range names {
+		if !byDigest && !matchesVersion(name, parseVersion(reference)) {
+			continue
+		}
+
+		artifact, err := d.artifact(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if byDigest && artifact.Digest.String() != reference {
+			continue
+		}
+
+		return artifact, nil
+	}
+
+	return nil, fmt.Errorf("release %s not found in %s", reference, d.dir)
+}
+
+// Fetch opens the file on disk.
+func (d *DirSource) Fetch(ctx context.Context, file File) (io.ReadCloser, error) {
+	return os.Open(file.Location)
+}
+
+func (d *DirSource) versionDirs() ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list release directory: %w", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if entry.IsDir() && parseVersion(entry.Name()) != nil {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (d *DirSource) artifact(ctx context.Context, name string) (*Artifact, error) {
+	root := filepath.Join(d.dir, name)
+
+	artifact := &Artifact{
+		Annotations: map[string]string{
+			ocispec.AnnotationVersion: name,
+		},
+	}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		dgst, err := d.fileDigest(path, info)
+		if err != nil {
+			return err
+		}
+
+		artifact.Files = append(artifact.Files, File{
+			Path:     filepath.ToSlash(rel),
+			Digest:   dgst,
+			Size:     info.Size(),
+			Mode:     info.Mode().Perm(),
+			Location: path,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release %s: %w", name, err)
+	}
+
+	artifact.Digest = listingDigest(artifact.Files)
+	artifact.Reference = fmt.Sprintf("%s@%s", root, artifact.Digest)
+
+	return artifact, nil
+}
+
+// listingDigest hashes the sorted paths, modes and digests of the files.
+func listingDigest(files []File) digest.Digest {
+	lines := make([]string, 0, len(files))
+
+	for _, file := range files {
+		lines = append(lines, fmt.Sprintf("%s %o %s\n", file.Path, file.Mode, file.Digest))
+	}
+
+	slices.Sort(lines)
+
+	return digest.FromString(strings.Join(lines, ""))
+}
+
+// fileDigest returns the digest of the file at path, hashing it only if it
+// changed since it was last hashed.
+func (d *DirSource) fileDigest(path string, info fs.FileInfo) (digest.Digest, error) {
+	d.mu.Lock()
+	cached, ok := d.digests[path]
+	d.mu.Unlock()
+
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.digest, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	dgst, err := digest.FromReader(f)
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.digests[path] = cachedDigest{digest: dgst, size: info.Size(), modTime: info.ModTime()}
+
+	return dgst, nil
+}
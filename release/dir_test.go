@@ -0,0 +1,63 @@
+package release
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSourceDigestCache(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "1.0.0", "myapp")
+
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(binary, []byte("first"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	source, err := NewDirSource(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolve := func() *Artifact {
+		t.Helper()
+
+		artifact, err := source.Resolve(context.Background(), "v1.0.0")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+
+		return artifact
+	}
+
+	first := resolve()
+
+	if again := resolve(); again.Digest != first.Digest {
+		t.Errorf("unchanged release resolved to %s, want %s", again.Digest, first.Digest)
+	}
+
+	if err := os.WriteFile(binary, []byte("second"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Coarse file system timestamps might not tell the writes apart
+	if err := os.Chtimes(binary, time.Time{}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := resolve()
+
+	if changed.Digest == first.Digest {
+		t.Error("changed release resolved to the digest of the original one")
+	}
+
+	if byDigest, err := source.Resolve(context.Background(), changed.Digest.String()); err != nil || byDigest.Digest != changed.Digest {
+		t.Errorf("Resolve(%s) = %v, %v", changed.Digest, byDigest, err)
+	}
+}
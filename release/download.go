@@ -0,0 +1,449 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// progressInterval limits how often progress is reported.
+const progressInterval = 500 * time.Millisecond
+
+// DownloadOptions control how release files are downloaded.
+type DownloadOptions struct {
+	// TempDir holds partially downloaded files, so interrupted downloads
+	// can be resumed. It must be on the same filesystem as the destination.
+	TempDir string
+
+	// RateLimit caps the download speed in bytes per second, 0 is unlimited
+	RateLimit int64
+
+	// Progress is called periodically while downloading, if set
+	Progress func(Progress)
+}
+
+// Progress reports the state of a download.
+type Progress struct {
+	Reference      string
+	File           string
+	Done           int64
+	Total          int64
+	BytesPerSecond int64
+	ETA            time.Duration
+}
+
+// Download downloads the files of the resolved artifact from the source into
+// destDir. Files are downloaded into the temp directory first and resumed if
+// a previous download was interrupted and the source supports seeking.
+// Every file is verified against its digest before it is moved into place.
+func Download(ctx context.Context, source Source, artifact *Artifact, destDir string, opts DownloadOptions) error {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create destination dir: %w", err)
+	}
+
+	if len(artifact.Files) == 0 {
+		return fmt.Errorf("%s contains no files", artifact.Reference)
+	}
+
+	var total int64
+
+	for _, file := range artifact.Files {
+		total += file.Size
+	}
+
+	tracker := newProgressTracker(artifact.Reference, total, opts.Progress)
+	limiter := newRateLimiter(opts.RateLimit)
+
+	for _, file := range artifact.Files {
+		if !filepath.IsLocal(file.Path) {
+			return fmt.Errorf("invalid file path %s", file.Path)
+		}
+
+		target := filepath.Join(destDir, file.Path)
+
+		if err := download(ctx, source, file, target, opts.TempDir, limiter, tracker); err != nil {
+			return fmt.Errorf("failed to download %s: %w", artifact.Reference, err)
+		}
+
+		if err := os.Chmod(target, file.Mode); err != nil {
+			return fmt.Errorf("failed to chmod %s: %w", file.Path, err)
+		}
+	}
+
+	tracker.finish()
+
+	return nil
+}
+
+// FetchFile downloads a single file of an artifact or patch to path, with
+// the same resumption and verification as Download.
+func FetchFile(ctx context.Context, source Source, file File, path string, opts DownloadOptions) error {
+	tracker := newProgressTracker(file.Path, file.Size, opts.Progress)
+
+	if err := download(ctx, source, file, path, opts.TempDir, newRateLimiter(opts.RateLimit), tracker); err != nil {
+		return err
+	}
+
+	tracker.finish()
+
+	return nil
+}
+
+// download fetches a file into a partial file in tempDir, resuming what a
+// previous attempt left behind, verifies it and moves it to target. Partial
+// files are kept on errors so the next attempt can resume.
+func download(ctx context.Context, source Source, file File, target, tempDir string, limiter *rateLimiter, tracker *progressTracker) error {
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	if err := file.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid digest for %s: %w", file.Path, err)
+	}
+
+	// Partial files are keyed by digest, a resumed download always
+	// continues the exact same content
+	partial := filepath.Join(tempDir, file.Digest.Encoded()+".partial")
+
+	out, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", partial, err)
+	}
+	defer out.Close()
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("failed to seek %s: %w", partial, err)
+	}
+
+	if offset > file.Size {
+		if err := truncate(out); err != nil {
+			return err
+		}
+
+		offset = 0
+	}
+
+	if offset < file.Size {
+		if offset, err = fetch(ctx, source, file, out, offset, limiter, tracker); err != nil {
+			return err
+		}
+	} else {
+		tracker.resume(file.Path, offset)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", partial, err)
+	}
+
+	if err := VerifyFileDigest(partial, file.Digest); err != nil {
+		// Start over next time, the partial content is unusable
+		os.Remove(partial)
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", file.Path, err)
+	}
+
+	if err := os.Rename(partial, target); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", file.Path, err)
+	}
+
+	return nil
+}
+
+// fetch writes the file from offset to out, which must be positioned at
+// offset. It falls back to downloading from the start if the source does
+// not support seeking.
+func fetch(ctx context.Context, source Source, file File, out *os.File, offset int64, limiter *rateLimiter, tracker *progressTracker) (int64, error) {
+	rc, err := source.Fetch(ctx, file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch %s: %w", file.Path, err)
+	}
+	defer rc.Close()
+
+	if offset > 0 {
+		seeker, ok := rc.(io.Seeker)
+
+		if ok {
+			_, err = seeker.Seek(offset, io.SeekStart)
+		}
+
+		if !ok || err != nil {
+			slog.Warn("source does not support resuming downloads, starting over", "file", file.Path, "error", err)
+
+			if err := truncate(out); err != nil {
+				return 0, err
+			}
+
+			offset = 0
+		} else {
+			slog.Info("resuming download", "file", file.Path, "offset", offset, "size", file.Size)
+		}
+	}
+
+	tracker.resume(file.Path, offset)
+
+	reader := io.LimitReader(limiter.reader(ctx, rc), file.Size-offset)
+
+	n, err := io.Copy(out, tracker.reader(reader))
+	offset += n
+
+	if err != nil {
+		return offset, fmt.Errorf("failed to download %s: %w", file.Path, err)
+	}
+
+	if offset != file.Size {
+		return offset, fmt.Errorf("failed to download %s: got %d of %d bytes", file.Path, offset, file.Size)
+	}
+
+	return offset, nil
+}
+
+func truncate(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate %s: %w", file.Name(), err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s: %w", file.Name(), err)
+	}
+
+	return nil
+}
+
+// VerifyFileDigest checks that the content of the file at path matches the
+// expected digest.
+func VerifyFileDigest(path string, expected digest.Digest) error {
+	if err := expected.Validate(); err != nil {
+		return fmt.Errorf("invalid digest for %s: %w", path, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	actual, err := expected.Algorithm().FromReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	if actual != expected {
+		return fmt.Errorf("digest mismatch for %s: expected %s, got %s", path, expected, actual)
+	}
+
+	return nil
+}
+
+// PruneDownloads removes the partial files in tempDir that belong to none of
+// the given files, e.g. those of a download that was given up for a newer
+// release.
+func PruneDownloads(tempDir string, files []File) error {
+	entries, err := os.ReadDir(tempDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to list download directory: %w", err)
+	}
+
+	keep := map[string]bool{}
+
+	for _, file := range files {
+		keep[file.Digest.Encoded()+".partial"] = true
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".partial") || keep[entry.Name()] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(tempDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+		}
+
+		slog.Debug("removed abandoned partial download", "file", entry.Name())
+	}
+
+	return nil
+}
+
+// rateLimiter caps the throughput of all readers created from it.
+type rateLimiter struct {
+	limit int64
+
+	mu      sync.Mutex
+	started time.Time
+	read    int64
+}
+
+func newRateLimiter(limit int64) *rateLimiter {
+	return &rateLimiter{limit: limit}
+}
+
+func (l *rateLimiter) reader(ctx context.Context, r io.Reader) io.Reader {
+	if l.limit <= 0 {
+		return r
+	}
+
+	return &limitedReader{ctx: ctx, r: r, limiter: l}
+}
+
+// wait blocks until reading n more bytes stays within the limit.
+func (l *rateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+
+	if l.started.IsZero() {
+		l.started = time.Now()
+	}
+
+	l.read += int64(n)
+	due := l.started.Add(time.Duration(float64(l.read) / float64(l.limit) * float64(time.Second)))
+
+	l.mu.Unlock()
+
+	delay := time.Until(due)
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type limitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	// Read in slices of at most a tenth of a second worth of data to keep
+	// the throughput smooth
+	if chunk := max(lr.limiter.limit/10, 1); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+
+	n, err := lr.r.Read(p)
+
+	if n > 0 {
+		if waitErr := lr.limiter.wait(lr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+// progressTracker aggregates the progress of a download and reports it.
+type progressTracker struct {
+	fn func(Progress)
+
+	mu       sync.Mutex
+	progress Progress
+	started  time.Time
+	resumed  int64
+	reported time.Time
+}
+
+func newProgressTracker(reference string, total int64, fn func(Progress)) *progressTracker {
+	return &progressTracker{
+		fn:      fn,
+		started: time.Now(),
+		progress: Progress{
+			Reference: reference,
+			Total:     total,
+		},
+	}
+}
+
+// resume starts tracking a file of which offset bytes are already present.
+func (t *progressTracker) resume(path string, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.File = path
+	t.progress.Done += offset
+	t.resumed += offset
+
+	t.report(true)
+}
+
+func (t *progressTracker) add(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.Done += n
+
+	t.report(false)
+}
+
+func (t *progressTracker) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.progress.ETA = 0
+
+	t.report(true)
+}
+
+// report calls the progress function at most every progressInterval unless
+// forced. Callers must hold mu.
+func (t *progressTracker) report(force bool) {
+	if t.fn == nil || (!force && time.Since(t.reported) < progressInterval) {
+		return
+	}
+
+	// Resumed bytes were not transferred now and don't count for the rate
+	if elapsed := time.Since(t.started).Seconds(); elapsed > 0 {
+		t.progress.BytesPerSecond = int64(float64(t.progress.Done-t.resumed) / elapsed)
+	}
+
+	if t.progress.BytesPerSecond > 0 {
+		remaining := t.progress.Total - t.progress.Done
+		t.progress.ETA = time.Duration(float64(remaining) / float64(t.progress.BytesPerSecond) * float64(time.Second)).Round(time.Second)
+	}
+
+	t.reported = time.Now()
+	t.fn(t.progress)
+}
+
+func (t *progressTracker) reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, tracker: t}
+}
+
+type progressReader struct {
+	r       io.Reader
+	tracker *progressTracker
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+
+	if n > 0 {
+		pr.tracker.add(int64(n))
+	}
+
+	return n, err
+}
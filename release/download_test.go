@@ -0,0 +1,89 @@
+package release
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestVerifyFileDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "myapp")
+
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		expected digest.Digest
+		wantErr  string
+	}{
+		{name: "matching", expected: digest.FromString("content")},
+		{name: "sha512", expected: digest.SHA512.FromString("content")},
+		{name: "mismatch", expected: digest.FromString("other"), wantErr: "digest mismatch"},
+		{name: "empty", expected: "", wantErr: "invalid digest"},
+		{name: "unsupported algorithm", expected: "md5:9a0364b9e99bb480dd25e1f0284c8555", wantErr: "invalid digest"},
+		{name: "malformed", expected: "sha256:xyz", wantErr: "invalid digest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyFileDigest(path, tt.expected)
+
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("VerifyFileDigest() error = %v", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("VerifyFileDigest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := VerifyFileDigest(filepath.Join(t.TempDir(), "missing"), digest.FromString("content")); err == nil {
+		t.Error("VerifyFileDigest() of a missing file succeeded")
+	}
+}
+
+func TestPruneDownloads(t *testing.T) {
+	dir := t.TempDir()
+
+	pending := File{Path: "myapp", Digest: digest.FromString("pending")}
+	abandoned := digest.FromString("abandoned")
+
+	for _, name := range []string{pending.Digest.Encoded() + ".partial", abandoned.Encoded() + ".partial", "unrelated"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := PruneDownloads(dir, []File{pending}); err != nil {
+		t.Fatalf("PruneDownloads() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	want := []string{pending.Digest.Encoded() + ".partial", "unrelated"}
+	slices.Sort(want)
+
+	if !slices.Equal(names, want) {
+		t.Errorf("PruneDownloads() left %v, want %v", names, want)
+	}
+
+	if err := PruneDownloads(filepath.Join(dir, "missing"), nil); err != nil {
+		t.Errorf("PruneDownloads() of a missing directory error = %v", err)
+	}
+}
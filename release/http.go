@@ -0,0 +1,366 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// maxIndexSize guards against index URLs serving arbitrarily large
+// documents.
+const maxIndexSize = 16 * 1024 * 1024
+
+// HTTPSource reads releases from a JSON index served over HTTP(S):
+//
+//	{
+//	  "releases": [{
+//	    "version": "1.2.3",
+//	    "annotations": {"dev.knockknock.rollout": "10"},
+//	    "files": [{
+//	      "path": "my-binary",
+//	      "url": "1.2.3/my-binary",
+//	      "digest": "sha256:...",
+//	      "size": 1234,
+//	      "mode": "0755"
+//	    }]
+//	  }]
+//	}
+//
+// File URLs may be relative to the index URL. Artifacts are pinned by the
+// digest of their release entry, so a release that is republished with
+// different content gets a different digest. The index is revalidated with
+// its ETag or modification time rather than downloaded on every request.
+type HTTPSource struct {
+	index      *url.URL
+	binaryName string
+	client     *http.Client
+
+	mu     sync.Mutex
+	cached *cachedIndex
+}
+
+// cachedIndex is the last index served, with its validators.
+type cachedIndex struct {
+	releases     []json.RawMessage
+	etag         string
+	lastModified string
+}
+
+type httpIndex struct {
+	Releases []json.RawMessage `json:"releases"`
+}
+
+type httpRelease struct {
+	Version     string            `json:"version"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Files       []httpFile        `json:"files"`
+}
+
+type httpFile struct {
+	Path       string        `json:"path"`
+	URL        string        `json:"url"`
+	Digest     digest.Digest `json:"digest"`
+	Size       int64         `json:"size"`
+	Mode       string        `json:"mode,omitempty"`
+	Entrypoint bool          `json:"entrypoint,omitempty"`
+}
+
+// NewHTTPSource returns a source reading the index at indexURL. Files without
+// a mode are executable if they are the entrypoint or named binaryName. A nil
+// client uses http.DefaultClient.
+func NewHTTPSource(indexURL, binaryName string, client *http.Client) (*HTTPSource, error) {
+	index, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index URL: %w", err)
+	}
+
+	if index.Scheme != "https" && index.Scheme != "http" {
+		return nil, fmt.Errorf("invalid index URL '%s', expected an http or https URL", indexURL)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTPSource{
+		index:      index,
+		binaryName: binaryName,
+		client:     client,
+	}, nil
+}
+
+// Versions lists the versions of all releases in the index.
+func (h *HTTPSource) Versions(ctx context.Context) (VersionSet, error) {
+	releases, err := h.releases(ctx)
+	if err != nil {
+		return VersionSet{}, err
+	}
+
+	tags := make([]string, 0, len(releases))
+
+	for _, release := range releases {
+		tags = append(tags, release.Version)
+	}
+
+	return ParseTags(tags), nil
+}
+
+// Resolve returns the release with the given version or entry digest.
+func (h *HTTPSource) Resolve(ctx context.Context, reference string) (*Artifact, error) {
+	raw, err := h.fetchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version := parseVersion(reference)
+
+	for _, entry := range raw {
+		var release httpRelease
+
+		if err := json.Unmarshal(entry, &release); err != nil {
+			return nil, fmt.Errorf("failed to parse index: %w", err)
+		}
+
+		dgst := digest.FromBytes(entry)
+
+		if dgst.String() != reference && !matchesVersion(release.Version, version) {
+			continue
+		}
+
+		return h.artifact(release, dgst)
+	}
+
+	return nil, fmt.Errorf("release %s not found in %s", reference, h.index.Redacted())
+}
+
+// Fetch opens the file's URL. The returned reader is seekable; seeking
+// issues a range request.
+func (h *HTTPSource) Fetch(ctx context.Context, file File) (io.ReadCloser, error) {
+	return &httpReader{
+		ctx:    ctx,
+		client: h.client,
+		url:    file.Location,
+	}, nil
+}
+
+func (h *HTTPSource) artifact(release httpRelease, dgst digest.Digest) (*Artifact, error) {
+	annotations := map[string]string{}
+
+	for key, value := range release.Annotations {
+		annotations[key] = value
+	}
+
+	if _, ok := annotations[ocispec.AnnotationVersion]; !ok {
+		annotations[ocispec.AnnotationVersion] = release.Version
+	}
+
+	artifact := &Artifact{
+		Reference:   fmt.Sprintf("%s@%s", h.index.Redacted(), dgst),
+		Digest:      dgst,
+		Annotations: annotations,
+	}
+
+	for _, f := range release.Files {
+		location, err := h.index.Parse(f.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL for %s: %w", f.Path, err)
+		}
+
+		if err := f.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid digest for %s: %w", f.Path, err)
+		}
+
+		annotations := map[string]string{
+			EntrypointAnnotation: strconv.FormatBool(f.Entrypoint),
+		}
+
+		if f.Mode != "" {
+			annotations[FileModeAnnotation] = f.Mode
+		}
+
+		file, err := NewFile(f.Path, annotations, h.binaryName)
+		if err != nil {
+			return nil, err
+		}
+
+		file.Digest = f.Digest
+		file.Size = f.Size
+		file.Location = location.String()
+
+		artifact.Files = append(artifact.Files, file)
+	}
+
+	return artifact, nil
+}
+
+func (h *HTTPSource) releases(ctx context.Context) ([]httpRelease, error) {
+	raw, err := h.fetchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]httpRelease, len(raw))
+
+	for i, entry := range raw {
+		if err := json.Unmarshal(entry, &releases[i]); err != nil {
+			return nil, fmt.Errorf("failed to parse index: %w", err)
+		}
+	}
+
+	return releases, nil
+}
+
+// fetchIndex returns the raw release entries, which are kept as served to
+// derive their digests.
+func (h *HTTPSource) fetchIndex(ctx context.Context) ([]json.RawMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.index.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	h.mu.Lock()
+	cached := h.cached
+	h.mu.Unlock()
+
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return cached.releases, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch index: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index: %w", err)
+	}
+
+	if len(body) > maxIndexSize {
+		return nil, fmt.Errorf("index exceeds %d bytes", maxIndexSize)
+	}
+
+	var index httpIndex
+
+	if err := json.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index: %w", err)
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+
+	if etag != "" || lastModified != "" {
+		h.mu.Lock()
+		h.cached = &cachedIndex{releases: index.Releases, etag: etag, lastModified: lastModified}
+		h.mu.Unlock()
+	}
+
+	return index.Releases, nil
+}
+
+// httpReader lazily requests a URL on the first read, starting at the
+// offset set by Seek.
+type httpReader struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *httpReader) Read(p []byte) (int, error) {
+	if r.body == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+
+	return n, err
+}
+
+// Seek only supports absolute offsets before the first read. It requests
+// the range right away, so that servers without range support fail the seek
+// rather than the following read.
+func (r *httpReader) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart || offset < 0 {
+		return r.offset, errors.New("unsupported seek")
+	}
+
+	if r.body != nil {
+		return r.offset, errors.New("seek after read")
+	}
+
+	r.offset = offset
+
+	if err := r.open(); err != nil {
+		r.offset = 0
+		return 0, err
+	}
+
+	return offset, nil
+}
+
+func (r *httpReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+
+	return r.body.Close()
+}
+
+func (r *httpReader) open() error {
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+
+	if r.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", r.offset))
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case r.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+	case r.offset == 0 && resp.StatusCode == http.StatusOK:
+	default:
+		resp.Body.Close()
+		return fmt.Errorf("unexpected response %s", resp.Status)
+	}
+
+	r.body = resp.Body
+
+	return nil
+}
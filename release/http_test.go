@@ -0,0 +1,54 @@
+package release
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPSourceRevalidatesIndex(t *testing.T) {
+	index := `{"releases":[{"version":"1.0.0","files":[{"path":"myapp","url":"1.0.0/myapp","digest":"sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae","size":3}]}]}`
+
+	var full, notModified atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(index))
+	}))
+	t.Cleanup(server.Close)
+
+	source, err := NewHTTPSource(server.URL+"/index.json", "myapp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := source.Versions(ctx); err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+
+	for range 3 {
+		artifact, err := source.Resolve(ctx, "1.0.0")
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+
+		if len(artifact.Files) != 1 || artifact.Files[0].Location != server.URL+"/1.0.0/myapp" {
+			t.Fatalf("Resolve() files = %+v", artifact.Files)
+		}
+	}
+
+	if full.Load() != 1 || notModified.Load() != 3 {
+		t.Errorf("index downloaded %d times and revalidated %d times, want 1 and 3", full.Load(), notModified.Load())
+	}
+}
@@ -0,0 +1,183 @@
+package release
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// SignatureAnnotation is the artifact annotation carrying a base64
+	// encoded signature of the binary.
+	SignatureAnnotation = "dev.knockknock.signature"
+
+	// RolloutAnnotation is the artifact annotation carrying the percentage of
+	// hosts (0-100) a release is offered to. Releases without it are offered
+	// to all hosts.
+	RolloutAnnotation = "dev.knockknock.rollout"
+
+	// PatchBaseAnnotation is the patch annotation naming the version the
+	// patch applies to.
+	PatchBaseAnnotation = "dev.knockknock.patch.base"
+
+	// FileModeAnnotation is the file annotation declaring its permission
+	// bits in octal, e.g. "0640".
+	FileModeAnnotation = "dev.knockknock.file.mode"
+
+	// EntrypointAnnotation is the file annotation marking the file that is
+	// executed, if it is not named like the binary.
+	EntrypointAnnotation = "dev.knockknock.file.entrypoint"
+)
+
+// Source is where releases are discovered and downloaded from.
+type Source interface {
+	// Versions lists all available versions.
+	Versions(ctx context.Context) (VersionSet, error)
+
+	// Resolve resolves a version or a source specific digest reference to an
+	// artifact, pinning all following operations to that exact content.
+	Resolve(ctx context.Context, reference string) (*Artifact, error)
+
+	// Fetch opens the content of a file of a resolved artifact or patch. If
+	// the returned reader implements io.Seeker, interrupted downloads are
+	// resumed from where they stopped.
+	Fetch(ctx context.Context, file File) (io.ReadCloser, error)
+}
+
+// SignatureSource is implemented by sources that publish signatures
+// detached from the artifact, in addition to the signature annotation.
+type SignatureSource interface {
+	Signatures(ctx context.Context, artifact *Artifact) ([][]byte, error)
+}
+
+// PatchSource is implemented by sources that publish binary patches.
+type PatchSource interface {
+	Patches(ctx context.Context, artifact *Artifact) ([]Patch, error)
+}
+
+// Artifact is a release resolved to its immutable digest.
+type Artifact struct {
+	// Reference is the fully qualified, digest pinned reference
+	// e.g., ghcr.io/org/repo@sha256:...
+	Reference string
+
+	Digest      digest.Digest
+	Annotations map[string]string
+	Files       []File
+
+	// Index is the digest of the image index the manifest was selected
+	// from for the running platform, empty for single-platform releases
+	Index digest.Digest
+}
+
+// File is a file of a release artifact.
+type File struct {
+	Path   string
+	Digest digest.Digest
+	Size   int64
+	Mode   os.FileMode
+
+	// Entrypoint marks the file that is executed
+	Entrypoint bool
+
+	// Location tells the source where to fetch the file from, e.g. a URL.
+	// Sources that address content by digest leave it empty.
+	Location string
+}
+
+// Patch is a set of binary patches from a base version to a release.
+type Patch struct {
+	Base  string
+	Files []File
+}
+
+// Rollout returns the percentage of hosts the artifact is rolled out to.
+func (a *Artifact) Rollout() (int, error) {
+	value, ok := a.Annotations[RolloutAnnotation]
+	if !ok {
+		return 100, nil
+	}
+
+	percentage, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil || percentage < 0 || percentage > 100 {
+		return 0, fmt.Errorf("invalid %s annotation '%s', expected a percentage between 0 and 100", RolloutAnnotation, value)
+	}
+
+	return percentage, nil
+}
+
+// Signatures returns all signatures published for the artifact, from the
+// signature annotation and, if the source supports it, detached ones.
+func Signatures(ctx context.Context, source Source, artifact *Artifact) ([][]byte, error) {
+	var signatures [][]byte
+
+	if encoded, ok := artifact.Annotations[SignatureAnnotation]; ok {
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature annotation: %w", err)
+		}
+
+		signatures = append(signatures, signature)
+	}
+
+	if source, ok := source.(SignatureSource); ok {
+		detached, err := source.Signatures(ctx, artifact)
+		if err != nil {
+			return nil, err
+		}
+
+		signatures = append(signatures, detached...)
+	}
+
+	return signatures, nil
+}
+
+// Patches returns the binary patches published for the artifact, or none if
+// the source does not support patches.
+func Patches(ctx context.Context, source Source, artifact *Artifact) ([]Patch, error) {
+	if source, ok := source.(PatchSource); ok {
+		return source.Patches(ctx, artifact)
+	}
+
+	return nil, nil
+}
+
+// NewFile builds a file from its annotations. Files without a declared mode
+// are executable if they are the entrypoint or named like the binary, and
+// read-only otherwise.
+func NewFile(path string, annotations map[string]string, binaryName string) (File, error) {
+	file := File{
+		Path: path,
+		Mode: 0644,
+	}
+
+	if value, ok := annotations[EntrypointAnnotation]; ok {
+		entrypoint, err := strconv.ParseBool(value)
+		if err != nil {
+			return File{}, fmt.Errorf("invalid %s annotation on %s: %w", EntrypointAnnotation, path, err)
+		}
+
+		file.Entrypoint = entrypoint
+	}
+
+	if file.Entrypoint || path == binaryName {
+		file.Mode = 0755
+	}
+
+	if value, ok := annotations[FileModeAnnotation]; ok {
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err != nil || os.FileMode(mode)&^os.ModePerm != 0 {
+			return File{}, fmt.Errorf("invalid %s annotation '%s' on %s, expected octal permission bits", FileModeAnnotation, value, path)
+		}
+
+		file.Mode = os.FileMode(mode)
+	}
+
+	return file, nil
+}
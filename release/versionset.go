@@ -0,0 +1,192 @@
+package release
+
+import (
+	"slices"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// VersionSet is an ascending, de-duplicated set of semver versions.
+// Versions of equal precedence (e.g. tags "1.2.3" and "v1.2.3", or versions
+// differing only in build metadata) are kept once, preferring the canonical
+// spelling. Version.Original() returns the tag a version was parsed from.
+type VersionSet struct {
+	versions []semver.Version
+}
+
+// NewVersionSet sorts and de-duplicates the given versions.
+func NewVersionSet(versions ...semver.Version) VersionSet {
+	sorted := slices.Clone(versions)
+
+	slices.SortStableFunc(sorted, func(a, b semver.Version) int {
+		if c := a.Compare(&b); c != 0 {
+			return c
+		}
+
+		// Equal precedence, order the preferred spelling first
+		return preference(a) - preference(b)
+	})
+
+	sorted = slices.CompactFunc(sorted, func(a, b semver.Version) bool {
+		return a.Equal(&b)
+	})
+
+	return VersionSet{versions: sorted}
+}
+
+// ParseTags builds a version set from registry tags, skipping tags that are
+// not valid semver. A leading "v" is accepted.
+func ParseTags(tags []string) VersionSet {
+	var versions []semver.Version
+
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+
+		if err != nil {
+			// Skip non-semver tags
+			continue
+		}
+
+		versions = append(versions, *v)
+	}
+
+	return NewVersionSet(versions...)
+}
+
+// preference ranks spellings of the same version, lower is preferred:
+// canonical tags first, then tags without build metadata.
+func preference(v semver.Version) int {
+	rank := 0
+
+	if v.Original() != v.String() {
+		rank++
+	}
+
+	if v.Metadata() != "" {
+		rank += 2
+	}
+
+	return rank
+}
+
+// Versions returns the versions in ascending order.
+func (s VersionSet) Versions() []semver.Version {
+	return slices.Clone(s.versions)
+}
+
+// Len returns the number of versions in the set.
+func (s VersionSet) Len() int {
+	return len(s.versions)
+}
+
+// Latest returns the highest version, or nil if the set is empty.
+func (s VersionSet) Latest() *semver.Version {
+	if len(s.versions) == 0 {
+		return nil
+	}
+
+	latest := s.versions[len(s.versions)-1]
+
+	return &latest
+}
+
+// Next returns the lowest version greater than v, or nil if there is none.
+func (s VersionSet) Next(v *semver.Version) *semver.Version {
+	i, found := s.search(v)
+
+	if found {
+		i++
+	}
+
+	if i >= len(s.versions) {
+		return nil
+	}
+
+	next := s.versions[i]
+
+	return &next
+}
+
+// Previous returns the highest version lower than v, or nil if there is
+// none.
+func (s VersionSet) Previous(v *semver.Version) *semver.Version {
+	i, _ := s.search(v)
+
+	if i == 0 {
+		return nil
+	}
+
+	previous := s.versions[i-1]
+
+	return &previous
+}
+
+// Find returns the version of equal precedence to v, as present in the set.
+func (s VersionSet) Find(v *semver.Version) (*semver.Version, bool) {
+	i, found := s.search(v)
+
+	if !found {
+		return nil, false
+	}
+
+	match := s.versions[i]
+
+	return &match, true
+}
+
+// Tag returns the tag under which the version spelled by reference is in
+// the set, e.g. "v1.2.3" for "1.2.3". References that are not a version in
+// the set are returned unchanged.
+func (s VersionSet) Tag(reference string) string {
+	v := parseVersion(reference)
+	if v == nil {
+		return reference
+	}
+
+	match, ok := s.Find(v)
+	if !ok {
+		return reference
+	}
+
+	return match.Original()
+}
+
+// Filter returns the versions for which keep returns true.
+func (s VersionSet) Filter(keep func(v *semver.Version) bool) VersionSet {
+	var versions []semver.Version
+
+	for _, v := range s.versions {
+		if keep(&v) {
+			versions = append(versions, v)
+		}
+	}
+
+	return VersionSet{versions: versions}
+}
+
+func (s VersionSet) search(v *semver.Version) (int, bool) {
+	return slices.BinarySearchFunc(s.versions, v, func(a semver.Version, b *semver.Version) int {
+		return a.Compare(b)
+	})
+}
+
+// parseVersion parses a tag, returning nil if it is not valid semver.
+func parseVersion(tag string) *semver.Version {
+	v, err := semver.NewVersion(tag)
+	if err != nil {
+		return nil
+	}
+
+	return v
+}
+
+// matchesVersion reports whether tag is a spelling of version.
+func matchesVersion(tag string, version *semver.Version) bool {
+	if version == nil {
+		return false
+	}
+
+	v := parseVersion(tag)
+
+	return v != nil && v.Equal(version)
+}
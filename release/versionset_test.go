@@ -0,0 +1,139 @@
+package release
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// originals returns the tags the versions were parsed from.
+func originals(versions []semver.Version) string {
+	var tags []string
+
+	for _, v := range versions {
+		tags = append(tags, v.Original())
+	}
+
+	return strings.Join(tags, ",")
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "empty"},
+		{name: "sorted by precedence", tags: []string{"1.10.0", "1.2.0", "1.9.1"}, want: "1.2.0,1.9.1,1.10.0"},
+		{name: "non-semver tags skipped", tags: []string{"latest", "1.0.0", "main", "sha256-abc.sig"}, want: "1.0.0"},
+		{name: "v prefix accepted", tags: []string{"v1.1.0", "1.0.0"}, want: "1.0.0,v1.1.0"},
+		{name: "canonical spelling preferred", tags: []string{"v1.0.0", "1.0.0"}, want: "1.0.0"},
+		{name: "canonical spelling preferred in any order", tags: []string{"1.0.0", "v1.0.0"}, want: "1.0.0"},
+		{name: "build metadata ignored", tags: []string{"1.0.0+build.2", "1.0.0"}, want: "1.0.0"},
+		{name: "v prefix preferred over build metadata", tags: []string{"1.0.0+build.2", "v1.0.0"}, want: "v1.0.0"},
+		{name: "prereleases before release", tags: []string{"1.0.0", "1.0.0-rc.1", "1.0.0-beta.2"}, want: "1.0.0-beta.2,1.0.0-rc.1,1.0.0"},
+		{name: "short versions", tags: []string{"1.2", "1"}, want: "1,1.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originals(ParseTags(tt.tags).Versions()); got != tt.want {
+				t.Errorf("ParseTags(%v) = %s, want %s", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionSetLookups(t *testing.T) {
+	set := ParseTags([]string{"1.0.0", "v1.1.0", "1.2.0-rc.1", "1.2.0", "2.0.0"})
+
+	tests := []struct {
+		version      string
+		wantNext     string
+		wantPrevious string
+		wantFind     string
+	}{
+		{version: "0.9.0", wantNext: "1.0.0"},
+		{version: "1.0.0", wantNext: "v1.1.0", wantFind: "1.0.0"},
+		{version: "1.1.0", wantNext: "1.2.0-rc.1", wantPrevious: "1.0.0", wantFind: "v1.1.0"},
+		{version: "1.1.5", wantNext: "1.2.0-rc.1", wantPrevious: "v1.1.0"},
+		{version: "v1.2.0", wantNext: "2.0.0", wantPrevious: "1.2.0-rc.1", wantFind: "1.2.0"},
+		{version: "2.0.0", wantPrevious: "1.2.0", wantFind: "2.0.0"},
+		{version: "3.0.0", wantPrevious: "2.0.0"},
+	}
+
+	tag := func(v *semver.Version) string {
+		if v == nil {
+			return ""
+		}
+
+		return v.Original()
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v := semver.MustParse(tt.version)
+
+			if got := tag(set.Next(v)); got != tt.wantNext {
+				t.Errorf("Next() = %q, want %q", got, tt.wantNext)
+			}
+
+			if got := tag(set.Previous(v)); got != tt.wantPrevious {
+				t.Errorf("Previous() = %q, want %q", got, tt.wantPrevious)
+			}
+
+			found, ok := set.Find(v)
+			if got := tag(found); got != tt.wantFind || ok != (tt.wantFind != "") {
+				t.Errorf("Find() = %q, %v, want %q", got, ok, tt.wantFind)
+			}
+		})
+	}
+}
+
+func TestVersionSetTag(t *testing.T) {
+	set := ParseTags([]string{"v1.10.0", "1.11.0", "latest"})
+
+	tests := []struct {
+		reference string
+		want      string
+	}{
+		{reference: "1.10.0", want: "v1.10.0"},
+		{reference: "v1.10.0", want: "v1.10.0"},
+		{reference: "1.10", want: "v1.10.0"},
+		{reference: "v1.11.0", want: "1.11.0"},
+		{reference: "1.12.0", want: "1.12.0"},
+		{reference: "latest", want: "latest"},
+		{reference: "sha256:0123", want: "sha256:0123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			if got := set.Tag(tt.reference); got != tt.want {
+				t.Errorf("Tag(%q) = %q, want %q", tt.reference, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionSetLatestAndFilter(t *testing.T) {
+	if latest := (VersionSet{}).Latest(); latest != nil {
+		t.Errorf("Latest() of an empty set = %v, want nil", latest)
+	}
+
+	set := ParseTags([]string{"1.0.0", "2.0.0-rc.1", "1.5.0"})
+
+	if latest := set.Latest(); latest.Original() != "2.0.0-rc.1" {
+		t.Errorf("Latest() = %v, want 2.0.0-rc.1", latest)
+	}
+
+	stable := set.Filter(func(v *semver.Version) bool { return v.Prerelease() == "" })
+
+	if got := originals(stable.Versions()); got != "1.0.0,1.5.0" {
+		t.Errorf("Filter() = %s, want 1.0.0,1.5.0", got)
+	}
+
+	if set.Len() != 3 {
+		t.Errorf("Filter() modified the original set, Len() = %d", set.Len())
+	}
+}
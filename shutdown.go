@@ -0,0 +1,120 @@
+package knockknock
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+var shutdown = struct {
+	once        sync.Once
+	mu          sync.Mutex
+	signal      syscall.Signal
+	gracePeriod time.Duration
+	ctx         context.Context
+	cancel      context.CancelFunc
+	hooks       []func()
+
+	// requested is closed once the shutdown signal was received, done once
+	// all callbacks have returned
+	requested chan struct{}
+	done      chan struct{}
+}{
+	signal:      syscall.SIGTERM,
+	gracePeriod: config.DefaultShutdownGracePeriod,
+	requested:   make(chan struct{}),
+	done:        make(chan struct{}),
+}
+
+// exit terminates the process, replaced in tests.
+var exit = os.Exit
+
+// Context returns a context that is cancelled when the supervisor asks the
+// application to shut down, e.g. before switching to a new version. The
+// application should finish in-flight work and return from its main
+// function; it is killed once the configured grace period has passed.
+func Context() context.Context {
+	listenForShutdown()
+
+	return shutdown.ctx
+}
+
+// OnShutdown registers a callback that is run when the supervisor asks the
+// application to shut down. Callbacks run in registration order and the
+// process exits once all of them have returned, even if the main function
+// returned earlier.
+//
+//	knockknock.OnShutdown(func() {
+//		server.Shutdown(context.Background())
+//	})
+func OnShutdown(fn func()) {
+	listenForShutdown()
+
+	shutdown.mu.Lock()
+	defer shutdown.mu.Unlock()
+
+	shutdown.hooks = append(shutdown.hooks, fn)
+}
+
+// listenForShutdown starts handling the shutdown signal. It is only
+// installed once the application opts in through Context or OnShutdown, so
+// applications that do neither keep the default signal behaviour.
+func listenForShutdown() {
+	shutdown.once.Do(func() {
+		shutdown.ctx, shutdown.cancel = context.WithCancel(context.Background())
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, shutdown.signal)
+
+		go func() {
+			sig := <-signals
+			slog.Info("shutdown requested by supervisor", "signal", sig)
+
+			close(shutdown.requested)
+			shutdown.cancel()
+
+			shutdown.mu.Lock()
+			hooks := shutdown.hooks
+			shutdown.mu.Unlock()
+
+			for _, hook := range hooks {
+				hook()
+			}
+
+			close(shutdown.done)
+
+			if len(hooks) == 0 {
+				// Context users exit by returning from their main function
+				return
+			}
+
+			exit(0)
+		}()
+	})
+}
+
+// waitForShutdown waits for the shutdown callbacks to return if shutdown
+// was requested, at most for the grace period after which the supervisor
+// kills the process anyway.
+func waitForShutdown() {
+	select {
+	case <-shutdown.requested:
+	default:
+		return
+	}
+
+	timer := time.NewTimer(shutdown.gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-shutdown.done:
+	case <-timer.C:
+		slog.Warn("shutdown callbacks did not return within the grace period")
+	}
+}
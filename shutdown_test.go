@@ -0,0 +1,54 @@
+package knockknock
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestShutdownWaitsForCallbacks(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		codes []int
+	)
+
+	exit = func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		codes = append(codes, code)
+	}
+	t.Cleanup(func() { exit = os.Exit })
+
+	shutdown.signal = syscall.SIGUSR1
+
+	var drained atomic.Bool
+
+	OnShutdown(func() {
+		time.Sleep(200 * time.Millisecond)
+		drained.Store(true)
+	})
+
+	// Like http.Server.ListenAndServe, return as soon as shutdown begins
+	runAsChild(func() {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatal(err)
+		}
+
+		<-Context().Done()
+	})
+
+	if !drained.Load() {
+		t.Fatal("process exited before the shutdown callback returned")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(codes) == 0 || codes[len(codes)-1] != 0 {
+		t.Errorf("exit codes = %v, want a clean exit", codes)
+	}
+}
@@ -0,0 +1,155 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Activate switches to a version that is already present in the versions
+// directory, without downloading anything. This allows jumping back several
+// releases or rolling forward again after a rollback. Quarantined versions
+// are refused.
+func (s *Supervisor) Activate(version string) error {
+	return s.activateVersion(version, false)
+}
+
+// ForceActivate is like Activate but also activates quarantined versions.
+func (s *Supervisor) ForceActivate(version string) error {
+	return s.activateVersion(version, true)
+}
+
+// activateVersion switches to an installed version, records the outcome in
+// the journal and restarts into it.
+func (s *Supervisor) activateVersion(version string, force bool) error {
+	if err := s.beginChange(); err != nil {
+		return err
+	}
+
+	entry := s.newJournalEntry(ActionActivate, TriggerManual)
+	entry.To = version
+
+	err := s.activate(version, entry, force)
+	s.record(entry, err)
+
+	if err != nil {
+		s.changeMu.Unlock()
+		return err
+	}
+
+	return s.restart()
+}
+
+func (s *Supervisor) activate(version string, entry *JournalEntry, force bool) error {
+	if err := s.ValidateActivate(version, force); err != nil {
+		return err
+	}
+
+	if metadata, err := s.Metadata(version); err == nil {
+		entry.Digest = metadata.Digest.String()
+	}
+
+	if err := s.switchVersion(version); err != nil {
+		return err
+	}
+
+	if err := s.startProbation(version); err != nil {
+		slog.Warn("failed to start probation", "version", version, "error", err)
+	}
+
+	return nil
+}
+
+// ValidateActivate checks whether the given version can be activated: it
+// must be installed, not already active, pass integrity verification and,
+// unless forced, not be quarantined.
+func (s *Supervisor) ValidateActivate(version string, force bool) error {
+	if version == "" || version == "." || version == ".." || strings.ContainsRune(version, filepath.Separator) {
+		return fmt.Errorf("invalid version '%s'", version)
+	}
+
+	info, err := os.Stat(filepath.Join(s.dataDir, "versions", version))
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("version %s is not installed", version)
+	}
+
+	if active, err := s.activeVersion(); err == nil && active == version {
+		return fmt.Errorf("version %s is already active", version)
+	}
+
+	// Versions installed before metadata was written, and the migrated
+	// legacy installation, can only be checked for a valid binary.
+	// Downloads are only moved into the versions directory once complete.
+	if err := s.verifyVersion(version); err != nil {
+		if !errors.Is(err, ErrNoMetadata) {
+			return fmt.Errorf("version %s failed verification: %w", version, err)
+		}
+
+		slog.Warn("version has no integrity metadata, skipping digest verification", "version", version)
+	}
+
+	if force {
+		return nil
+	}
+
+	q, err := s.quarantined(version)
+	if err != nil {
+		return err
+	}
+
+	if q != nil {
+		return fmt.Errorf("version %s is quarantined since %s (%s), force the activation to switch to it anyway", version, q.Since.Format(time.DateTime), q.Reason)
+	}
+
+	return nil
+}
+
+// switchVersion atomically points the current symlink to the given version.
+// The previously active version is kept as a backup for rollbacks.
+func (s *Supervisor) switchVersion(version string) error {
+	versionDir := filepath.Join(s.dataDir, "versions", version)
+	currentLink := filepath.Join(s.dataDir, "current")
+
+	// Backup existing current symlink if it exists. Nanoseconds keep the
+	// names unique when switching more than once per second.
+	if _, err := os.Lstat(currentLink); err == nil {
+		timestamp := time.Now().Format("20060102-150405.000000000")
+		backupLink := filepath.Join(s.dataDir, fmt.Sprintf("previous-%s", timestamp))
+
+		target, err := os.Readlink(currentLink)
+		if err != nil {
+			return fmt.Errorf("failed to read current symlink: %w", err)
+		}
+
+		if err := os.Symlink(target, backupLink); err != nil {
+			return fmt.Errorf("failed to create backup symlink: %w", err)
+		}
+	}
+
+	// Atomically swap the current symlink to point to new version
+	tempLink := filepath.Join(s.dataDir, fmt.Sprintf("current.tmp.%d", time.Now().UnixNano()))
+
+	if err := os.Symlink(versionDir, tempLink); err != nil {
+		return fmt.Errorf("failed to create temporary symlink: %w", err)
+	}
+
+	if err := os.Rename(tempLink, currentLink); err != nil {
+		os.Remove(tempLink)
+		return fmt.Errorf("failed to swap symlink: %w", err)
+	}
+
+	// Update the binary symlink in the bin directory
+	if err := s.updateBinSymlink(); err != nil {
+		return fmt.Errorf("failed to update bin symlink: %w", err)
+	}
+
+	if err := s.cleanupOldBackups(3); err != nil {
+		slog.Warn("failed to cleanup old backups", "error", err)
+	}
+
+	return nil
+}
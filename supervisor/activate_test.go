@@ -0,0 +1,69 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateActivate(t *testing.T) {
+	source := newFakeSource()
+	source.add("2.0.0", nil, map[string][]byte{"myapp": elfBinary(t)})
+
+	s := newTestSupervisor(t, source)
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+
+	// Installed without metadata, e.g. by earlier releases
+	binaries := map[string][]byte{
+		legacyVersion: elfBinary(t),
+		"1.5.0":       elfBinary(t),
+		"1.4.0":       []byte("not a binary"),
+	}
+
+	for version, binary := range binaries {
+		dir := filepath.Join(s.dataDir, "versions", version)
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, "myapp"), binary, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		version    string
+		quarantine bool
+		force      bool
+		wantErr    bool
+	}{
+		{name: "staged", version: "2.0.0"},
+		{name: "not installed", version: "3.0.0", wantErr: true},
+		{name: "invalid", version: "../2.0.0", wantErr: true},
+		{name: "legacy without metadata", version: legacyVersion},
+		{name: "version without metadata", version: "1.5.0"},
+		{name: "invalid binary without metadata", version: "1.4.0", wantErr: true},
+		{name: "quarantined", version: "2.0.0", quarantine: true, wantErr: true},
+		{name: "quarantined and forced", version: "2.0.0", quarantine: true, force: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.quarantine {
+				s.quarantine(tt.version, "crash loop")
+				t.Cleanup(func() { s.ClearQuarantine("") })
+			}
+
+			err := s.ValidateActivate(tt.version, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateActivate(%q, %v) error = %v, wantErr %v", tt.version, tt.force, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package supervisor
+
+import (
+	"debug/elf"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// elfMachines maps GOARCH values to the ELF machine of their binaries.
+var elfMachines = map[string]elf.Machine{
+	"386":      elf.EM_386,
+	"amd64":    elf.EM_X86_64,
+	"arm":      elf.EM_ARM,
+	"arm64":    elf.EM_AARCH64,
+	"loong64":  elf.EM_LOONGARCH,
+	"mips":     elf.EM_MIPS,
+	"mipsle":   elf.EM_MIPS,
+	"mips64":   elf.EM_MIPS,
+	"mips64le": elf.EM_MIPS,
+	"ppc64":    elf.EM_PPC64,
+	"ppc64le":  elf.EM_PPC64,
+	"riscv64":  elf.EM_RISCV,
+	"s390x":    elf.EM_S390,
+}
+
+// verifyMachine checks that an ELF binary was built for the architecture
+// the supervisor runs on.
+func verifyMachine(r io.ReaderAt) error {
+	file, err := elf.NewFile(r)
+	if err != nil {
+		return fmt.Errorf("failed to parse ELF header: %w", err)
+	}
+
+	expected, ok := elfMachines[runtime.GOARCH]
+	if !ok {
+		// Unknown architecture, nothing to compare against
+		return nil
+	}
+
+	if file.Machine != expected {
+		return fmt.Errorf("binary is built for %s, expected %s (%s)", file.Machine, expected, runtime.GOARCH)
+	}
+
+	return nil
+}
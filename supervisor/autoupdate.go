@@ -0,0 +1,146 @@
+package supervisor
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// maxBackoffFactor caps the poll delay after repeated registry errors at a
+// multiple of the configured interval.
+const maxBackoffFactor = 8
+
+// autoUpdate polls the registry on the configured interval and installs the
+// newest version eligible under the update policy. Registry errors back off
+// exponentially so a degraded registry is not hammered by the whole fleet.
+// With maintenance windows configured, updates found outside of a window are
+// staged and installed once the next window opens.
+func (s *Supervisor) autoUpdate(ctx context.Context) {
+	cfg := s.config.AutoUpdate
+	failures := 0
+
+	slog.Info("auto-update enabled", "interval", cfg.Interval, "policy", cfg.Policy)
+
+	for {
+		delay := pollDelay(cfg, failures)
+
+		if status := s.Maintenance(); status != nil && !status.Open {
+			delay = min(delay, time.Until(status.NextWindow))
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if err := s.pollUpdate(ctx); err != nil {
+			failures++
+			slog.Warn("auto-update poll failed", "error", err, "failures", failures)
+
+			continue
+		}
+
+		failures = 0
+	}
+}
+
+// pollUpdate checks the registry once and installs an eligible update if
+// one is available.
+func (s *Supervisor) pollUpdate(ctx context.Context) error {
+	versions, err := s.refreshVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	candidates, err := s.candidates(filterChannel(s.Channel(), versions))
+	if err != nil {
+		return err
+	}
+
+	update, err := s.newestRolledOut(ctx, withPolicy(s.currentVersion, candidates, s.config.AutoUpdate.Policy))
+	if err != nil {
+		return err
+	}
+
+	if update == nil {
+		slog.Debug("auto-update found no eligible version", "current", s.currentVersion)
+		return nil
+	}
+
+	if !s.inMaintenanceWindow() {
+		return s.stageUpdate(ctx, update)
+	}
+
+	slog.Info("auto-update installing new version", "current", s.currentVersion, "version", update)
+
+	return s.update(ctx, update.String(), TriggerAutoUpdate, false)
+}
+
+// stageUpdate downloads an update found outside of a maintenance window so
+// that installing it inside the window does not depend on the registry
+// download. Versions that are already staged are not staged again.
+func (s *Supervisor) stageUpdate(ctx context.Context, update *semver.Version) error {
+	if _, err := s.Metadata(update.String()); err == nil {
+		slog.Debug("auto-update waiting for maintenance window", "version", update)
+		return nil
+	}
+
+	slog.Info("auto-update staging new version until the next maintenance window", "current", s.currentVersion, "version", update)
+
+	_, err := s.stage(ctx, update.String(), TriggerAutoUpdate, false)
+
+	return err
+}
+
+// wakeAutoUpdate makes the auto-updater poll right away.
+func (s *Supervisor) wakeAutoUpdate() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pollDelay returns the time to wait before the next poll. Each consecutive
+// failure doubles the interval, up to maxBackoffFactor times the interval.
+func pollDelay(cfg *config.AutoUpdateConfig, failures int) time.Duration {
+	maxDelay := cfg.Interval * maxBackoffFactor
+	delay := cfg.Interval
+
+	for i := 0; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	delay = min(delay, maxDelay)
+
+	if cfg.Jitter > 0 {
+		delay += rand.N(cfg.Jitter)
+	}
+
+	return delay
+}
+
+// withPolicy returns the versions the update policy allows to update to from
+// current.
+func withPolicy(current *semver.Version, versions release.VersionSet, policy config.UpdatePolicy) release.VersionSet {
+	return versions.Filter(func(v *semver.Version) bool {
+		switch policy {
+		case config.UpdatePolicyMinor:
+			return v.Major() == current.Major()
+		case config.UpdatePolicyPatch:
+			return v.Major() == current.Major() && v.Minor() == current.Minor()
+		}
+
+		return true
+	})
+}
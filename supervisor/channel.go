@@ -0,0 +1,100 @@
+package supervisor
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+func validateChannel(cfg *config.Config) error {
+	for _, channel := range cfg.Channels {
+		if channel.Name == cfg.Channel {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown release channel '%s'", cfg.Channel)
+}
+
+// Channel returns the active release channel. A channel selected at runtime
+// takes precedence over the configured one.
+func (s *Supervisor) Channel() config.Channel {
+	name := s.config.Channel
+
+	if st, err := s.readState(); err == nil && st.Channel != "" {
+		name = st.Channel
+	}
+
+	if channel, ok := s.lookupChannel(name); ok {
+		return channel
+	}
+
+	slog.Warn("unknown release channel, falling back to stable", "channel", name)
+
+	return config.ChannelStable
+}
+
+// Channels returns all available release channels.
+func (s *Supervisor) Channels() []config.Channel {
+	return s.config.Channels
+}
+
+// SetChannel switches the release channel. The selection is persisted and
+// survives restarts.
+func (s *Supervisor) SetChannel(name string) error {
+	if _, ok := s.lookupChannel(name); !ok {
+		return fmt.Errorf("unknown release channel '%s'", name)
+	}
+
+	err := s.updateState(func(st *state) {
+		st.Channel = name
+	})
+
+	if err != nil {
+		return err
+	}
+
+	slog.Info("switched release channel", "channel", name)
+
+	return nil
+}
+
+func (s *Supervisor) lookupChannel(name string) (config.Channel, bool) {
+	i := slices.IndexFunc(s.config.Channels, func(channel config.Channel) bool {
+		return channel.Name == name
+	})
+
+	if i < 0 {
+		return config.Channel{}, false
+	}
+
+	return s.config.Channels[i], true
+}
+
+// filterChannel returns the versions that are part of the channel.
+func filterChannel(channel config.Channel, versions release.VersionSet) release.VersionSet {
+	return versions.Filter(func(v *semver.Version) bool {
+		return inChannel(channel, v)
+	})
+}
+
+func inChannel(channel config.Channel, v *semver.Version) bool {
+	if v.Prerelease() == "" {
+		return true
+	}
+
+	identifier, _, _ := strings.Cut(v.Prerelease(), ".")
+
+	for _, prerelease := range channel.Prereleases {
+		if prerelease == "*" || prerelease == identifier {
+			return true
+		}
+	}
+
+	return false
+}
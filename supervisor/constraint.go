@@ -0,0 +1,47 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// parseConstraint parses the configured version constraint. An empty
+// constraint allows every version.
+func parseConstraint(constraint string) (*semver.Constraints, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint '%s': %w", constraint, err)
+	}
+
+	return c, nil
+}
+
+// checkConstraint returns an error explaining why the configured constraint
+// rejects the version, or nil if it is allowed.
+func (s *Supervisor) checkConstraint(version *semver.Version) error {
+	if s.constraint == nil {
+		return nil
+	}
+
+	if ok, errs := s.constraint.Validate(version); !ok {
+		return fmt.Errorf("version %s rejected by constraint '%s': %w", version, s.config.Constraint, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// withinConstraint filters versions rejected by the configured constraint.
+func (s *Supervisor) withinConstraint(versions release.VersionSet) release.VersionSet {
+	if s.constraint == nil {
+		return versions
+	}
+
+	return versions.Filter(s.constraint.Check)
+}
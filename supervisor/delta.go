@@ -0,0 +1,144 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/zeitlos/knockknock/delta"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// errNoPatch is returned when no patch applies to the active version.
+var errNoPatch = errors.New("no patch for the active version")
+
+// downloadDelta builds the artifact's files in versionDir by patching the
+// active version's files, instead of downloading them in full. Every
+// resulting file is verified against the artifact's digests.
+func (s *Supervisor) downloadDelta(ctx context.Context, artifact *release.Artifact, versionDir string) error {
+	base, err := s.activeVersion()
+	if err != nil || len(artifact.Files) == 0 {
+		return errNoPatch
+	}
+
+	patches, err := release.Patches(ctx, s.source, artifact)
+	if err != nil {
+		return err
+	}
+
+	var patch *release.Patch
+
+	for i := range patches {
+		if patches[i].Base == base {
+			patch = &patches[i]
+			break
+		}
+	}
+
+	if patch == nil {
+		return errNoPatch
+	}
+
+	// Patching a tampered base would produce garbage, which the digest
+	// check would catch, but only after downloading the patch
+	if err := s.verifyVersion(base); err != nil {
+		return fmt.Errorf("base version %s failed verification: %w", base, err)
+	}
+
+	tempDir, err := os.MkdirTemp(s.dataDir, "patch-")
+	if err != nil {
+		return fmt.Errorf("failed to create patch directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	for i, file := range artifact.Files {
+		if !filepath.IsLocal(file.Path) {
+			return fmt.Errorf("invalid file path %s", file.Path)
+		}
+
+		patchFile, ok := findFile(patch.Files, file.Path)
+		if !ok {
+			return fmt.Errorf("patch from %s does not cover %s", base, file.Path)
+		}
+
+		patchPath := filepath.Join(tempDir, fmt.Sprintf("%d.patch", i))
+
+		if err := release.FetchFile(ctx, s.source, patchFile, patchPath, s.downloadOptions()); err != nil {
+			return err
+		}
+
+		basePath := filepath.Join(s.dataDir, "versions", base, file.Path)
+		targetPath := filepath.Join(versionDir, file.Path)
+
+		if err := applyPatch(targetPath, basePath, patchPath); err != nil {
+			return fmt.Errorf("failed to patch %s: %w", file.Path, err)
+		}
+
+		if err := release.VerifyFileDigest(targetPath, file.Digest); err != nil {
+			return err
+		}
+
+		if err := os.Chmod(targetPath, file.Mode); err != nil {
+			return fmt.Errorf("failed to chmod %s: %w", file.Path, err)
+		}
+	}
+
+	slog.Info("applied delta update", "base", base, "files", len(artifact.Files))
+
+	return nil
+}
+
+func findFile(files []release.File, path string) (release.File, bool) {
+	for _, file := range files {
+		if file.Path == path {
+			return file, true
+		}
+	}
+
+	return release.File{}, false
+}
+
+// applyPatch writes the result of patching basePath with patchPath to
+// targetPath.
+func applyPatch(targetPath, basePath, patchPath string) error {
+	base, err := os.Open(basePath)
+	if err != nil {
+		return fmt.Errorf("failed to open base: %w", err)
+	}
+	defer base.Close()
+
+	baseInfo, err := base.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat base: %w", err)
+	}
+
+	patch, err := os.Open(patchPath)
+	if err != nil {
+		return fmt.Errorf("failed to open patch: %w", err)
+	}
+	defer patch.Close()
+
+	patchInfo, err := patch.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat patch: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	target, err := os.Create(targetPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", targetPath, err)
+	}
+	defer target.Close()
+
+	if err := delta.Apply(target, base, baseInfo.Size(), patch, patchInfo.Size()); err != nil {
+		return err
+	}
+
+	return target.Close()
+}
@@ -0,0 +1,228 @@
+package supervisor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+func TestDownloadChecksVersionLabel(t *testing.T) {
+	source := newFakeSource()
+	binary := elfBinary(t)
+
+	source.add("1.2.3", versionAnnotation("1.2.3"), map[string][]byte{"myapp": binary})
+	source.add("9.9.9", versionAnnotation("9.9.9"), map[string][]byte{"myapp": append(binary, 9)})
+
+	s := newTestSupervisor(t, source, func(c *config.Config) { c.WithConstraint("<2") })
+
+	pinned, err := source.Resolve(context.Background(), "9.9.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		reference string
+		wantErr   string
+	}{
+		{name: "matching label", reference: "1.2.3"},
+		{name: "label spelled differently", reference: "v1.2.3@" + mustDigest(t, source, "1.2.3")},
+		{name: "relabeled digest", reference: "1.2.3@" + pinned.Digest.String(), wantErr: "annotated as version 9.9.9"},
+		{name: "digest only", reference: pinned.Digest.String(), wantErr: "rejected by constraint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Stage(context.Background(), tt.reference)
+
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Stage() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Stage() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustDigest(t *testing.T, source *fakeSource, tag string) string {
+	t.Helper()
+
+	artifact, err := source.Resolve(context.Background(), tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return artifact.Digest.String()
+}
+
+func TestFailedDownloadIsNotInstalled(t *testing.T) {
+	binary := elfBinary(t)
+
+	tests := []struct {
+		name      string
+		configure func(*config.Config)
+		failFetch string
+	}{
+		{name: "interrupted download", failFetch: "lib/plugin.so"},
+		{
+			name:      "missing signature",
+			configure: func(c *config.Config) { c.WithSigningKeys(ed25519.PublicKey(make([]byte, ed25519.PublicKeySize))) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newFakeSource()
+			source.add("2.0.0", nil, map[string][]byte{"myapp": binary, "lib/plugin.so": []byte("plugin")})
+
+			if tt.failFetch != "" {
+				source.failFetch[tt.failFetch] = true
+			}
+
+			var configure []func(*config.Config)
+
+			if tt.configure != nil {
+				configure = append(configure, tt.configure)
+			}
+
+			s := newTestSupervisor(t, source, configure...)
+
+			if _, err := s.Stage(context.Background(), "2.0.0"); err == nil {
+				t.Fatal("Stage() succeeded, want an error")
+			}
+
+			if err := s.ValidateActivate("2.0.0", false); err == nil {
+				t.Fatal("ValidateActivate() accepted a failed download")
+			}
+
+			if _, err := os.Stat(filepath.Join(s.dataDir, "versions", "2.0.0")); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("version directory exists after a failed download: %v", err)
+			}
+
+			if leftovers, _ := filepath.Glob(filepath.Join(s.dataDir, "download-*")); len(leftovers) > 0 {
+				t.Errorf("download directories left behind: %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestStageReplacesEarlierDownload(t *testing.T) {
+	source := newFakeSource()
+	source.add("2.0.0", nil, map[string][]byte{"myapp": elfBinary(t)})
+
+	s := newTestSupervisor(t, source)
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+
+	// The tag was moved to different content
+	source.add("2.0.0", nil, map[string][]byte{"myapp": append(elfBinary(t), 1), "README": []byte("readme")})
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+
+	if err := s.ValidateActivate("2.0.0", false); err != nil {
+		t.Fatalf("ValidateActivate() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.dataDir, "versions", "2.0.0", "README")); err != nil {
+		t.Errorf("restaged version is missing its files: %v", err)
+	}
+}
+
+func TestVersionSpellings(t *testing.T) {
+	source := newFakeSource()
+	source.add("v2.0.0", nil, map[string][]byte{"myapp": elfBinary(t)})
+
+	s := newTestSupervisor(t, source)
+
+	for _, reference := range []string{"2.0.0", "v2.0.0"} {
+		version, err := s.Stage(context.Background(), reference)
+		if err != nil {
+			t.Fatalf("Stage(%q) error = %v", reference, err)
+		}
+
+		if version != "2.0.0" {
+			t.Errorf("Stage(%q) = %s, want 2.0.0", reference, version)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dataDir, "versions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dirs []string
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+
+	if strings.Join(dirs, ",") != "2.0.0" {
+		t.Errorf("version directories = %v, want [2.0.0]", dirs)
+	}
+
+	s.quarantine("v2.0.0", "crash loop")
+
+	for _, reference := range []string{"2.0.0", "v2.0.0"} {
+		if err := s.ValidateUpdate(reference, false); err == nil {
+			t.Errorf("ValidateUpdate(%q) accepted a quarantined version", reference)
+		}
+	}
+
+	candidates, err := s.candidates(release.ParseTags([]string{"v2.0.0", "2.1.0"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if candidates.Len() != 1 {
+		t.Errorf("candidates = %v, want only 2.1.0", candidates.Versions())
+	}
+
+	if err := s.ClearQuarantine("2.0.0"); err != nil {
+		t.Errorf("ClearQuarantine() error = %v", err)
+	}
+}
+
+func TestStagePrunesAbandonedDownloads(t *testing.T) {
+	source := newFakeSource()
+	binary := elfBinary(t)
+
+	source.add("2.0.0", nil, map[string][]byte{"myapp": binary, "lib/plugin.so": []byte("plugin")})
+	source.add("2.1.0", nil, map[string][]byte{"myapp": append(binary, 1)})
+
+	s := newTestSupervisor(t, source)
+	downloads := filepath.Join(s.dataDir, "downloads")
+
+	source.failFetch["lib/plugin.so"] = true
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err == nil {
+		t.Fatal("Stage() succeeded, want an error")
+	}
+
+	if partials, _ := filepath.Glob(filepath.Join(downloads, "*.partial")); len(partials) == 0 {
+		t.Fatal("interrupted download left no partial file to resume")
+	}
+
+	delete(source.failFetch, "lib/plugin.so")
+
+	if _, err := s.Stage(context.Background(), "2.1.0"); err != nil {
+		t.Fatalf("Stage() error = %v", err)
+	}
+
+	if partials, _ := filepath.Glob(filepath.Join(downloads, "*.partial")); len(partials) > 0 {
+		t.Errorf("partial files of the abandoned download were kept: %v", partials)
+	}
+}
@@ -0,0 +1,267 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// fakeSource is an in-memory release source.
+type fakeSource struct {
+	mu sync.Mutex
+
+	// releases by tag
+	releases map[string]*fakeRelease
+
+	// failFetch makes fetching the file with the given path fail
+	failFetch map[string]bool
+
+	// err makes every request fail
+	err error
+
+	// resolveErr makes resolving releases fail, while versions are still
+	// listed
+	resolveErr error
+
+	resolves int
+}
+
+type fakeRelease struct {
+	annotations map[string]string
+	files       map[string][]byte
+	modes       map[string]uint32
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		releases:  map[string]*fakeRelease{},
+		failFetch: map[string]bool{},
+	}
+}
+
+// add publishes a release under tag with the given files. The binary gets
+// an executable mode.
+func (f *fakeSource) add(tag string, annotations map[string]string, files map[string][]byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	f.releases[tag] = &fakeRelease{annotations: annotations, files: files}
+}
+
+func (f *fakeSource) Versions(ctx context.Context) (release.VersionSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return release.VersionSet{}, f.err
+	}
+
+	var tags []string
+
+	for tag := range f.releases {
+		tags = append(tags, tag)
+	}
+
+	return release.ParseTags(tags), nil
+}
+
+func (f *fakeSource) Resolve(ctx context.Context, reference string) (*release.Artifact, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.resolves++
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.resolveErr != nil {
+		return nil, f.resolveErr
+	}
+
+	// Versions resolve whichever way their tag is spelled, like in the
+	// real sources
+	var tags []string
+
+	for tag := range f.releases {
+		tags = append(tags, tag)
+	}
+
+	reference = release.ParseTags(tags).Tag(reference)
+
+	for tag, r := range f.releases {
+		artifact, err := r.artifact(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag == reference || artifact.Digest.String() == reference {
+			return artifact, nil
+		}
+	}
+
+	return nil, fmt.Errorf("release %s: not found", reference)
+}
+
+func (f *fakeSource) Fetch(ctx context.Context, file release.File) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	if f.failFetch[file.Path] {
+		return nil, errors.New("connection reset")
+	}
+
+	for _, r := range f.releases {
+		for _, data := range r.files {
+			if digest.FromBytes(data) == file.Digest {
+				return io.NopCloser(bytes.NewReader(data)), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("blob %s: not found", file.Digest)
+}
+
+func (f *fakeSource) resolveCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.resolves
+}
+
+func (r *fakeRelease) artifact(tag string) (*release.Artifact, error) {
+	annotations := map[string]string{}
+
+	for key, value := range r.annotations {
+		annotations[key] = value
+	}
+
+	artifact := &release.Artifact{Annotations: annotations}
+
+	var paths []string
+
+	for path := range r.files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	var listing strings.Builder
+
+	for _, path := range paths {
+		file, err := release.NewFile(path, nil, "myapp")
+		if err != nil {
+			return nil, err
+		}
+
+		file.Digest = digest.FromBytes(r.files[path])
+		file.Size = int64(len(r.files[path]))
+
+		artifact.Files = append(artifact.Files, file)
+		fmt.Fprintf(&listing, "%s %s\n", file.Path, file.Digest)
+	}
+
+	for key, value := range annotations {
+		fmt.Fprintf(&listing, "%s=%s\n", key, value)
+	}
+
+	artifact.Digest = digest.FromString(tag + "\n" + listing.String())
+	artifact.Reference = "fake@" + artifact.Digest.String()
+
+	return artifact, nil
+}
+
+// elfBinary returns a minimal ELF header for the running architecture,
+// enough to pass binary verification.
+func elfBinary(t *testing.T) []byte {
+	t.Helper()
+
+	machine, ok := elfMachines[runtime.GOARCH]
+	if !ok {
+		machine = elf.EM_X86_64
+	}
+
+	header := elf.Header64{
+		Type:    uint16(elf.ET_EXEC),
+		Machine: uint16(machine),
+		Version: uint32(elf.EV_CURRENT),
+		Ehsize:  64,
+	}
+
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+
+	if err := binary.Write(&buf, binary.LittleEndian, header); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// newTestSupervisor returns a supervisor with its data in a temporary
+// directory, reading releases from source.
+func newTestSupervisor(t *testing.T, source release.Source, configure ...func(*config.Config)) *Supervisor {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	cfg := config.New("myapp").
+		WithSource(source).
+		WithVersion("1.0.0").
+		WithBinaryDir(dir + "/bin").
+		WithVersionsDir(dir + "/lib").
+		WithRolloutSeed("test-host")
+
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return s
+}
+
+func versionAnnotation(version string) map[string]string {
+	return map[string]string{ocispec.AnnotationVersion: version}
+}
+
+func mustVersion(t *testing.T, v string) *semver.Version {
+	t.Helper()
+
+	version, err := semver.NewVersion(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return version
+}
@@ -0,0 +1,297 @@
+package supervisor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Trigger describes what caused a version change.
+type Trigger string
+
+const (
+	TriggerManual        Trigger = "manual"
+	TriggerAutoUpdate    Trigger = "auto-update"
+	TriggerCrashRollback Trigger = "crash-rollback"
+	TriggerProbationFail Trigger = "probation-fail"
+	TriggerIntegrityFail Trigger = "integrity-fail"
+
+	// TriggerMigrated marks changes made before the journal existed, taken
+	// from the backup symlinks of upgraded installations
+	TriggerMigrated Trigger = "migrated"
+)
+
+// Outcome is the result of a version change.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailed  Outcome = "failed"
+)
+
+// Action is the kind of version change.
+type Action string
+
+const (
+	ActionUpdate   Action = "update"
+	ActionRollback Action = "rollback"
+	ActionActivate Action = "activate"
+	ActionStage    Action = "stage"
+)
+
+// JournalEntry is a single version change recorded in the update journal.
+type JournalEntry struct {
+	Time    time.Time `json:"time"`
+	Action  Action    `json:"action"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Digest  string    `json:"digest,omitempty"`
+	Trigger Trigger   `json:"trigger"`
+	Outcome Outcome   `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func (s *Supervisor) journalPath() string {
+	return filepath.Join(s.dataDir, "journal.jsonl")
+}
+
+// newJournalEntry starts an entry for a version change away from the active
+// version.
+func (s *Supervisor) newJournalEntry(action Action, trigger Trigger) *JournalEntry {
+	from, err := s.activeVersion()
+	if err != nil {
+		from = s.config.Version
+	}
+
+	return &JournalEntry{
+		Action:  action,
+		From:    from,
+		Trigger: trigger,
+	}
+}
+
+// record completes the entry with the outcome of err and appends it to the
+// journal. Failing to write the journal never fails the operation itself.
+func (s *Supervisor) record(entry *JournalEntry, err error) {
+	entry.Time = time.Now()
+	entry.Outcome = OutcomeSuccess
+
+	if err != nil {
+		entry.Outcome = OutcomeFailed
+		entry.Error = err.Error()
+	}
+
+	if err := s.appendJournal(entry); err != nil {
+		slog.Warn("failed to write update journal", "error", err)
+	}
+}
+
+// appendJournal appends the entries as JSON lines and syncs them to disk.
+func (s *Supervisor) appendJournal(entries ...*JournalEntry) error {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	var data []byte
+
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+
+		data = append(append(data, line...), '\n')
+	}
+
+	file, err := os.OpenFile(s.journalPath(), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	if err := dropPartialLine(file); err != nil {
+		return fmt.Errorf("failed to repair journal: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	return nil
+}
+
+// dropPartialLine truncates a last line left without a newline, e.g. by a
+// crash while it was written, so that the next entry starts on a line of
+// its own.
+func dropPartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] == '\n' {
+		return nil
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, info.Size()))
+	if err != nil {
+		return err
+	}
+
+	slog.Warn("dropping partially written journal entry")
+
+	return file.Truncate(int64(bytes.LastIndexByte(data, '\n') + 1))
+}
+
+// seedJournal starts the journal of installations upgraded from releases
+// without one with the version changes recorded by their backup symlinks.
+// It does nothing once the journal exists.
+func (s *Supervisor) seedJournal() error {
+	if _, err := os.Stat(s.journalPath()); !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	backups, err := s.getBackupSymlinks()
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing was installed yet
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to list backup symlinks: %w", err)
+	}
+
+	// Each backup points to the version that was replaced at the time in
+	// its name, by the version of the next backup or the active version
+	var versions []string
+
+	for _, backup := range backups {
+		target, err := os.Readlink(backup)
+		if err != nil {
+			return fmt.Errorf("failed to read backup symlink: %w", err)
+		}
+
+		versions = append(versions, filepath.Base(target))
+	}
+
+	if active, err := s.activeVersion(); err == nil {
+		versions = append(versions, active)
+	} else {
+		versions = append(versions, "")
+	}
+
+	var entries []*JournalEntry
+
+	for i, backup := range backups {
+		timestamp := strings.TrimPrefix(filepath.Base(backup), "previous-")
+
+		// Fractional seconds of newer backups are parsed as well
+		t, err := time.ParseInLocation("20060102-150405", timestamp, time.Local)
+		if err != nil {
+			slog.Warn("skipping backup symlink without timestamp", "backup", backup)
+			continue
+		}
+
+		entries = append(entries, &JournalEntry{
+			Time:    t,
+			Action:  migratedAction(versions[i], versions[i+1]),
+			From:    versions[i],
+			To:      versions[i+1],
+			Trigger: TriggerMigrated,
+			Outcome: OutcomeSuccess,
+		})
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	slog.Info("seeding update journal from backup symlinks", "entries", len(entries))
+
+	return s.appendJournal(entries...)
+}
+
+// migratedAction tells rollbacks from updates for changes taken from backup
+// symlinks.
+func migratedAction(from, to string) Action {
+	if to != "" && compareVersionNames(to, from) < 0 {
+		return ActionRollback
+	}
+
+	return ActionUpdate
+}
+
+// readJournal returns all journal entries in the order they were written.
+// Lines that cannot be decoded, e.g. a partial write during a crash, are
+// skipped.
+func (s *Supervisor) readJournal() ([]JournalEntry, error) {
+	s.journalMu.Lock()
+	defer s.journalMu.Unlock()
+
+	file, err := os.Open(s.journalPath())
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []JournalEntry
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry JournalEntry
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			slog.Warn("skipping invalid journal entry", "error", err)
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return entries, nil
+}
+
+// History returns the recorded version changes, most recent first.
+func (s *Supervisor) History() []JournalEntry {
+	entries, err := s.readJournal()
+
+	if err != nil {
+		slog.Warn("failed to read update journal", "error", err)
+		return []JournalEntry{}
+	}
+
+	slices.Reverse(entries)
+
+	return entries
+}
@@ -0,0 +1,82 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSeedJournalFromBackups(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource())
+
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		if err := os.MkdirAll(filepath.Join(s.dataDir, "versions", version), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Backups as written by earlier releases, and with nanoseconds
+	links := map[string]string{
+		"previous-20260101-120000":           "1.0.0",
+		"previous-20260102-120000.000000500": "1.2.0",
+		"current":                            "1.1.0",
+	}
+
+	for name, version := range links {
+		if err := os.Symlink(filepath.Join(s.dataDir, "versions", version), filepath.Join(s.dataDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range 2 {
+		if err := s.seedJournal(); err != nil {
+			t.Fatalf("seedJournal() error = %v", err)
+		}
+	}
+
+	want := []JournalEntry{
+		{Time: time.Date(2026, 1, 2, 12, 0, 0, 500, time.Local), Action: ActionRollback, From: "1.2.0", To: "1.1.0"},
+		{Time: time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local), Action: ActionUpdate, From: "1.0.0", To: "1.2.0"},
+	}
+
+	history := s.History()
+	if len(history) != len(want) {
+		t.Fatalf("History() = %+v, want %d entries", history, len(want))
+	}
+
+	for i, entry := range history {
+		if !entry.Time.Equal(want[i].Time) || entry.Action != want[i].Action || entry.From != want[i].From || entry.To != want[i].To {
+			t.Errorf("History()[%d] = %+v, want %+v", i, entry, want[i])
+		}
+
+		if entry.Trigger != TriggerMigrated || entry.Outcome != OutcomeSuccess {
+			t.Errorf("History()[%d] trigger %s, outcome %s", i, entry.Trigger, entry.Outcome)
+		}
+	}
+}
+
+func TestAppendJournalAfterPartialWrite(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource())
+
+	s.record(&JournalEntry{Action: ActionUpdate, From: "1.0.0", To: "1.1.0"}, nil)
+
+	// A crash while the next entry was written
+	file, err := os.OpenFile(s.journalPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.WriteString(`{"time":"2026-`); err != nil {
+		t.Fatal(err)
+	}
+
+	file.Close()
+
+	s.record(&JournalEntry{Action: ActionUpdate, From: "1.1.0", To: "1.2.0"}, nil)
+
+	history := s.History()
+	if len(history) != 2 || history[0].To != "1.2.0" || history[1].To != "1.1.0" {
+		t.Errorf("History() = %+v, want both complete entries", history)
+	}
+}
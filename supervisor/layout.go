@@ -0,0 +1,106 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/zeitlos/knockknock/release"
+)
+
+// entrypoint returns the file of the artifact that is executed: the file
+// marked as entrypoint, or else the file named like the binary.
+func (s *Supervisor) entrypoint(artifact *release.Artifact) (*release.File, error) {
+	var entrypoint, binary *release.File
+
+	seen := map[string]bool{}
+
+	for i, file := range artifact.Files {
+		if !filepath.IsLocal(file.Path) {
+			return nil, fmt.Errorf("invalid file path %s", file.Path)
+		}
+
+		if seen[filepath.Clean(file.Path)] {
+			return nil, fmt.Errorf("file %s is declared more than once", file.Path)
+		}
+
+		seen[filepath.Clean(file.Path)] = true
+
+		if file.Path == s.config.BinaryName {
+			binary = &artifact.Files[i]
+		}
+
+		if !file.Entrypoint {
+			continue
+		}
+
+		if entrypoint != nil {
+			return nil, fmt.Errorf("multiple entrypoints declared: %s and %s", entrypoint.Path, file.Path)
+		}
+
+		entrypoint = &artifact.Files[i]
+	}
+
+	switch {
+	case entrypoint == nil && binary == nil:
+		return nil, fmt.Errorf("%s has no file named %s and declares no entrypoint", artifact.Reference, s.config.BinaryName)
+	case entrypoint == nil:
+		entrypoint = binary
+	case binary != nil && binary != entrypoint:
+		return nil, fmt.Errorf("entrypoint %s conflicts with file %s", entrypoint.Path, binary.Path)
+	}
+
+	if entrypoint.Mode&0111 == 0 {
+		return nil, fmt.Errorf("entrypoint %s is not executable (mode %s)", entrypoint.Path, entrypoint.Mode)
+	}
+
+	return entrypoint, nil
+}
+
+// linkEntrypoint makes an entrypoint that is not named like the binary
+// available under the binary name, where the supervisor executes it.
+func (s *Supervisor) linkEntrypoint(versionDir string, entrypoint *release.File) error {
+	if entrypoint.Path == s.config.BinaryName {
+		return nil
+	}
+
+	link := filepath.Join(versionDir, s.config.BinaryName)
+
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace entrypoint link: %w", err)
+	}
+
+	if err := os.Symlink(entrypoint.Path, link); err != nil {
+		return fmt.Errorf("failed to link entrypoint %s: %w", entrypoint.Path, err)
+	}
+
+	return nil
+}
+
+// verifyFiles checks that every recorded file exists in versionDir with the
+// recorded permissions and content.
+func verifyFiles(versionDir string, files []FileMetadata) error {
+	for _, file := range files {
+		path := filepath.Join(versionDir, file.Path)
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return fmt.Errorf("file %s is missing: %w", file.Path, err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("file %s is not a regular file", file.Path)
+		}
+
+		// Metadata of older releases does not record modes
+		if file.Mode != 0 && info.Mode().Perm() != file.Mode.Perm() {
+			return fmt.Errorf("file %s has mode %s, expected %s", file.Path, info.Mode().Perm(), file.Mode.Perm())
+		}
+
+		if err := release.VerifyFileDigest(path, file.Digest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
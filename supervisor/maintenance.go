@@ -0,0 +1,273 @@
+package supervisor
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+// window is a parsed maintenance window. Windows whose end is not after
+// their start wrap past midnight and belong to the day they start on.
+type window struct {
+	days  [7]bool
+	start time.Duration
+	end   time.Duration
+}
+
+// parseMaintenance parses the configured maintenance windows. It returns
+// nil if updates are not restricted. Like config.WithMaintenance, windows
+// are evaluated in local time unless a location is set.
+func parseMaintenance(cfg *config.MaintenanceConfig) ([]window, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+
+	if len(cfg.Windows) == 0 {
+		return nil, fmt.Errorf("maintenance requires at least one window")
+	}
+
+	var windows []window
+
+	for _, spec := range cfg.Windows {
+		w, err := parseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window '%s': %w", spec, err)
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+// parseWindow parses a window such as "Tue-Thu 02:00-04:00",
+// "Sat,Sun 00:00-06:00" or "22:00-02:00".
+func parseWindow(spec string) (window, error) {
+	// Accept en dashes as commonly written in change calendars
+	fields := strings.Fields(strings.ReplaceAll(spec, "–", "-"))
+
+	var w window
+
+	switch len(fields) {
+	case 1:
+		for i := range w.days {
+			w.days[i] = true
+		}
+	case 2:
+		if err := parseDays(fields[0], &w.days); err != nil {
+			return window{}, err
+		}
+
+		fields = fields[1:]
+	default:
+		return window{}, fmt.Errorf("expected '[days] HH:MM-HH:MM'")
+	}
+
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return window{}, fmt.Errorf("expected a time range 'HH:MM-HH:MM'")
+	}
+
+	var err error
+
+	if w.start, err = parseTimeOfDay(from); err != nil {
+		return window{}, err
+	}
+
+	if w.start == 24*time.Hour {
+		return window{}, fmt.Errorf("24:00 can only end a window")
+	}
+
+	if w.end, err = parseTimeOfDay(to); err != nil {
+		return window{}, err
+	}
+
+	if w.start == w.end {
+		return window{}, fmt.Errorf("window must not be empty")
+	}
+
+	return w, nil
+}
+
+// parseDays parses comma separated weekdays and weekday ranges into days.
+// Ranges may wrap around the end of the week, e.g. "Fri-Mon".
+func parseDays(spec string, days *[7]bool) error {
+	for part := range strings.SplitSeq(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, err := parseWeekday(from)
+		if err != nil {
+			return err
+		}
+
+		last := first
+
+		if isRange {
+			if last, err = parseWeekday(to); err != nil {
+				return err
+			}
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+
+			if d == last {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	if len(name) >= 3 {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.HasPrefix(strings.ToLower(d.String()), strings.ToLower(name)) {
+				return d, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("invalid weekday '%s'", name)
+}
+
+// parseTimeOfDay parses "HH:MM" into the duration since midnight. "24:00"
+// denotes the end of the day.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether t lies within the window.
+func (w window) contains(t time.Time) bool {
+	offset := sinceMidnight(t)
+	day := t.Weekday()
+
+	if w.start < w.end {
+		return w.days[day] && offset >= w.start && offset < w.end
+	}
+
+	// Wrapping windows started either today or yesterday
+	yesterday := (day + 6) % 7
+
+	return (w.days[day] && offset >= w.start) || (w.days[yesterday] && offset < w.end)
+}
+
+// nextStart returns the first time after t the window opens.
+func (w window) nextStart(t time.Time) time.Time {
+	for i := range 8 {
+		day := t.AddDate(0, 0, i)
+		start := time.Date(day.Year(), day.Month(), day.Day(), int(w.start/time.Hour), int(w.start%time.Hour/time.Minute), 0, 0, t.Location())
+
+		if w.days[day.Weekday()] && start.After(t) {
+			return start
+		}
+	}
+
+	return time.Time{}
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
+
+// MaintenanceStatus reports the state of the maintenance windows.
+type MaintenanceStatus struct {
+	// Open is set while updates may be activated, either because a window
+	// is open or because of a manual override
+	Open bool
+
+	// NextWindow is when the next maintenance window opens
+	NextWindow time.Time
+
+	// OverrideUntil is set while a manual override is in effect
+	OverrideUntil time.Time
+}
+
+// Maintenance returns the state of the maintenance windows, or nil if
+// updates are not restricted to maintenance windows.
+func (s *Supervisor) Maintenance() *MaintenanceStatus {
+	if s.windows == nil {
+		return nil
+	}
+
+	now := time.Now().In(s.config.Maintenance.Location)
+	status := &MaintenanceStatus{}
+
+	if st, err := s.readState(); err == nil && now.Before(st.MaintenanceOverride) {
+		status.Open = true
+		status.OverrideUntil = st.MaintenanceOverride
+	}
+
+	for _, w := range s.windows {
+		if w.contains(now) {
+			status.Open = true
+		}
+
+		if next := w.nextStart(now); status.NextWindow.IsZero() || next.Before(status.NextWindow) {
+			status.NextWindow = next
+		}
+	}
+
+	return status
+}
+
+// inMaintenanceWindow reports whether automatic updates may be activated
+// now.
+func (s *Supervisor) inMaintenanceWindow() bool {
+	status := s.Maintenance()
+
+	return status == nil || status.Open
+}
+
+// OverrideMaintenance allows automatic updates to be activated outside of
+// maintenance windows for the given duration, e.g. to roll out an urgent
+// fix. The auto-updater checks for updates right away. A duration of zero
+// ends an override early.
+func (s *Supervisor) OverrideMaintenance(duration time.Duration) error {
+	if s.windows == nil {
+		return fmt.Errorf("no maintenance windows configured")
+	}
+
+	if duration < 0 {
+		return fmt.Errorf("invalid override duration %s", duration)
+	}
+
+	var until time.Time
+
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+
+	err := s.updateState(func(st *state) {
+		st.MaintenanceOverride = until
+	})
+	if err != nil {
+		return err
+	}
+
+	if duration > 0 {
+		slog.Warn("maintenance windows overridden", "until", until)
+		s.wakeAutoUpdate()
+	} else {
+		slog.Info("maintenance override ended")
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+// at parses a time such as "Mon 2026-03-23 02:00" in loc.
+func at(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.ParseInLocation("Mon 2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func mustParseWindow(t *testing.T, spec string) window {
+	t.Helper()
+
+	w, err := parseWindow(spec)
+	if err != nil {
+		t.Fatalf("parseWindow(%q) error = %v", spec, err)
+	}
+
+	return w
+}
+
+func TestParseWindow(t *testing.T) {
+	everyDay := [7]bool{true, true, true, true, true, true, true}
+
+	tests := []struct {
+		spec      string
+		wantDays  [7]bool
+		wantStart time.Duration
+		wantEnd   time.Duration
+		wantErr   bool
+	}{
+		{spec: "02:00-04:00", wantDays: everyDay, wantStart: 2 * time.Hour, wantEnd: 4 * time.Hour},
+		{spec: "22:30-02:15", wantDays: everyDay, wantStart: 22*time.Hour + 30*time.Minute, wantEnd: 2*time.Hour + 15*time.Minute},
+		{spec: "Sat 00:00-24:00", wantDays: [7]bool{time.Saturday: true}, wantEnd: 24 * time.Hour},
+		{spec: "Tue-Thu 02:00-04:00", wantDays: [7]bool{time.Tuesday: true, time.Wednesday: true, time.Thursday: true}, wantStart: 2 * time.Hour, wantEnd: 4 * time.Hour},
+		{spec: "Sat,Sun 00:00-06:00", wantDays: [7]bool{time.Sunday: true, time.Saturday: true}, wantEnd: 6 * time.Hour},
+		{spec: "Fri-Mon 01:00-02:00", wantDays: [7]bool{time.Sunday: true, time.Monday: true, time.Friday: true, time.Saturday: true}, wantStart: time.Hour, wantEnd: 2 * time.Hour},
+		{spec: "sunday,Wed 01:00-02:00", wantDays: [7]bool{time.Sunday: true, time.Wednesday: true}, wantStart: time.Hour, wantEnd: 2 * time.Hour},
+		{spec: "Mon–Fri 01:00–02:00", wantDays: [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true}, wantStart: time.Hour, wantEnd: 2 * time.Hour},
+		{spec: "Thu-Thu 01:00-02:00", wantDays: [7]bool{time.Thursday: true}, wantStart: time.Hour, wantEnd: 2 * time.Hour},
+		{spec: "", wantErr: true},
+		{spec: "02:00", wantErr: true},
+		{spec: "02:00-02:00", wantErr: true},
+		{spec: "24:00-02:00", wantErr: true},
+		{spec: "02:00-25:00", wantErr: true},
+		{spec: "2am-4am", wantErr: true},
+		{spec: "Mo 02:00-04:00", wantErr: true},
+		{spec: "Mon-Xyz 02:00-04:00", wantErr: true},
+		{spec: "Mon 02:00-04:00 UTC", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			w, err := parseWindow(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWindow(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if w.days != tt.wantDays || w.start != tt.wantStart || w.end != tt.wantEnd {
+				t.Errorf("parseWindow(%q) = %+v, want days %v, %s-%s", tt.spec, w, tt.wantDays, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	tests := []struct {
+		window string
+		at     string
+		want   bool
+	}{
+		{window: "02:00-04:00", at: "Mon 2026-03-23 02:00", want: true},
+		{window: "02:00-04:00", at: "Mon 2026-03-23 03:59", want: true},
+		{window: "02:00-04:00", at: "Mon 2026-03-23 04:00", want: false},
+		{window: "02:00-04:00", at: "Mon 2026-03-23 01:59", want: false},
+
+		// Wrapping past midnight belongs to the day the window starts on
+		{window: "Fri 22:00-02:00", at: "Fri 2026-03-27 23:00", want: true},
+		{window: "Fri 22:00-02:00", at: "Sat 2026-03-28 01:00", want: true},
+		{window: "Fri 22:00-02:00", at: "Sat 2026-03-28 02:00", want: false},
+		{window: "Fri 22:00-02:00", at: "Fri 2026-03-27 01:00", want: false},
+		{window: "Fri 22:00-02:00", at: "Sat 2026-03-28 23:00", want: false},
+
+		// Wrapping past the end of the week
+		{window: "Sat 22:00-02:00", at: "Sun 2026-03-29 01:00", want: true},
+		{window: "Sun 22:00-02:00", at: "Mon 2026-03-30 01:00", want: true},
+		{window: "Sun 22:00-02:00", at: "Tue 2026-03-31 01:00", want: false},
+		{window: "Fri-Mon 01:00-02:00", at: "Sun 2026-03-29 01:30", want: true},
+		{window: "Fri-Mon 01:00-02:00", at: "Tue 2026-03-24 01:30", want: false},
+
+		// 24:00 ends the window at midnight
+		{window: "Sat 20:00-24:00", at: "Sat 2026-03-28 23:59", want: true},
+		{window: "Sat 20:00-24:00", at: "Sun 2026-03-29 00:00", want: false},
+		{window: "Sat 00:00-24:00", at: "Sat 2026-03-28 00:00", want: true},
+		{window: "Sat 00:00-24:00", at: "Fri 2026-03-27 23:59", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window+" at "+tt.at, func(t *testing.T) {
+			w := mustParseWindow(t, tt.window)
+
+			if got := w.contains(at(t, time.UTC, tt.at)); got != tt.want {
+				t.Errorf("contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowNextStart(t *testing.T) {
+	tests := []struct {
+		window string
+		at     string
+		want   string
+	}{
+		{window: "02:00-04:00", at: "Mon 2026-03-23 01:00", want: "Mon 2026-03-23 02:00"},
+		{window: "02:00-04:00", at: "Mon 2026-03-23 02:00", want: "Tue 2026-03-24 02:00"},
+		{window: "02:00-04:00", at: "Mon 2026-03-23 03:00", want: "Tue 2026-03-24 02:00"},
+		{window: "Tue-Thu 02:00-04:00", at: "Thu 2026-03-26 05:00", want: "Tue 2026-03-31 02:00"},
+		{window: "Fri 22:00-02:00", at: "Sat 2026-03-28 01:00", want: "Fri 2026-04-03 22:00"},
+		{window: "Mon 02:00-04:00", at: "Mon 2026-03-23 02:30", want: "Mon 2026-03-30 02:00"},
+		{window: "Sat,Sun 00:00-24:00", at: "Sat 2026-03-28 12:00", want: "Sun 2026-03-29 00:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window+" at "+tt.at, func(t *testing.T) {
+			w := mustParseWindow(t, tt.window)
+
+			if got, want := w.nextStart(at(t, time.UTC, tt.at)), at(t, time.UTC, tt.want); !got.Equal(want) {
+				t.Errorf("nextStart() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestWindowDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Clocks go from 02:00 to 03:00 on 2026-03-29 and from 03:00 back to
+	// 02:00 on 2026-10-25
+	tests := []struct {
+		name     string
+		window   string
+		at       time.Time
+		wantOpen bool
+		wantNext time.Time
+	}{
+		{
+			name:     "window by wall clock before spring forward",
+			window:   "01:00-04:00",
+			at:       time.Date(2026, 3, 29, 3, 30, 0, 0, berlin),
+			wantOpen: true,
+			wantNext: time.Date(2026, 3, 30, 1, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window opening in the skipped hour",
+			window:   "02:30-04:00",
+			at:       time.Date(2026, 3, 29, 1, 0, 0, 0, berlin),
+			wantNext: time.Date(2026, 3, 29, 3, 30, 0, 0, berlin),
+		},
+		{
+			name:     "next window across spring forward",
+			window:   "Mon 02:00-04:00",
+			at:       time.Date(2026, 3, 28, 12, 0, 0, 0, berlin),
+			wantNext: time.Date(2026, 3, 30, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "window by wall clock after fall back",
+			window:   "Sun 02:00-03:00",
+			at:       time.Date(2026, 10, 25, 2, 30, 0, 0, berlin),
+			wantOpen: true,
+			wantNext: time.Date(2026, 11, 1, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "next window across fall back",
+			window:   "Mon 02:00-04:00",
+			at:       time.Date(2026, 10, 24, 12, 0, 0, 0, berlin),
+			wantNext: time.Date(2026, 10, 26, 2, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := mustParseWindow(t, tt.window)
+
+			if got := w.contains(tt.at); got != tt.wantOpen {
+				t.Errorf("contains(%s) = %v, want %v", tt.at, got, tt.wantOpen)
+			}
+
+			if got := w.nextStart(tt.at); !got.Equal(tt.wantNext) {
+				t.Errorf("nextStart(%s) = %s, want %s", tt.at, got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestMaintenanceDefaultsToLocalTime(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource(), func(cfg *config.Config) {
+		// Configured without WithMaintenance
+		cfg.Maintenance = &config.MaintenanceConfig{Windows: []string{"00:00-24:00"}}
+	})
+
+	if got := s.config.Maintenance.Location; got != time.Local {
+		t.Errorf("Location = %v, want local time", got)
+	}
+
+	if status := s.Maintenance(); status == nil || !status.Open {
+		t.Errorf("Maintenance() = %+v, want an open window", status)
+	}
+}
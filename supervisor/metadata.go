@@ -0,0 +1,151 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// ErrNoMetadata is returned when a version has no recorded integrity
+// metadata, e.g. legacy installations or versions installed by older
+// releases of knockknock.
+var ErrNoMetadata = errors.New("no integrity metadata recorded")
+
+// Metadata records what was installed into a versions/<v> directory. It is
+// stored next to the directory as versions/<v>.json.
+type Metadata struct {
+	Version     string         `json:"version"`
+	Reference   string         `json:"reference"`
+	Digest      digest.Digest  `json:"digest"`
+	Index       digest.Digest  `json:"index,omitempty"`
+	Files       []FileMetadata `json:"files"`
+	InstalledAt time.Time      `json:"installed_at"`
+}
+
+type FileMetadata struct {
+	Path       string        `json:"path"`
+	Digest     digest.Digest `json:"digest"`
+	Size       int64         `json:"size"`
+	Mode       os.FileMode   `json:"mode,omitempty"`
+	Entrypoint bool          `json:"entrypoint,omitempty"`
+}
+
+func newMetadata(version string, artifact *release.Artifact) *Metadata {
+	metadata := &Metadata{
+		Version:     version,
+		Reference:   artifact.Reference,
+		Digest:      artifact.Digest,
+		Index:       artifact.Index,
+		InstalledAt: time.Now(),
+	}
+
+	for _, file := range artifact.Files {
+		metadata.Files = append(metadata.Files, FileMetadata{
+			Path:       file.Path,
+			Digest:     file.Digest,
+			Size:       file.Size,
+			Mode:       file.Mode,
+			Entrypoint: file.Entrypoint,
+		})
+	}
+
+	return metadata
+}
+
+func (s *Supervisor) metadataPath(version string) string {
+	return filepath.Join(s.dataDir, "versions", version+".json")
+}
+
+// writeMetadata atomically writes the metadata file for a version.
+func (s *Supervisor) writeMetadata(metadata *Metadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	path := s.metadataPath(metadata.Version)
+	tempPath := fmt.Sprintf("%s.tmp.%d", path, time.Now().UnixNano())
+
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move metadata into place: %w", err)
+	}
+
+	return nil
+}
+
+// Metadata returns the recorded integrity metadata of an installed version.
+func (s *Supervisor) Metadata(version string) (*Metadata, error) {
+	data, err := os.ReadFile(s.metadataPath(version))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoMetadata
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	var metadata Metadata
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+
+	return &metadata, nil
+}
+
+// Verify re-checks an installed version against the digests recorded when it
+// was downloaded. An empty version verifies the currently active one.
+func (s *Supervisor) Verify(version string) error {
+	if version == "" {
+		current, err := s.activeVersion()
+		if err != nil {
+			return err
+		}
+
+		version = current
+	}
+
+	return s.verifyVersion(version)
+}
+
+// activeVersion returns the name of the version directory the current
+// symlink points to.
+func (s *Supervisor) activeVersion() (string, error) {
+	target, err := os.Readlink(filepath.Join(s.dataDir, "current"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read current symlink: %w", err)
+	}
+
+	return filepath.Base(target), nil
+}
+
+func (s *Supervisor) verifyVersion(version string) error {
+	versionDir := filepath.Join(s.dataDir, "versions", version)
+
+	if err := verifyBinary(filepath.Join(versionDir, s.config.BinaryName)); err != nil {
+		return err
+	}
+
+	metadata, err := s.Metadata(version)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyFiles(versionDir, metadata.Files); err != nil {
+		return fmt.Errorf("version %s: %w", version, err)
+	}
+
+	return nil
+}
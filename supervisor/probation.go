@@ -0,0 +1,169 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+// defaultProbation replaces zero values in probation configured without
+// config.WithProbation with their defaults.
+func defaultProbation(probation *config.ProbationConfig) {
+	if probation == nil {
+		return
+	}
+
+	if probation.Deadline <= 0 {
+		probation.Deadline = config.DefaultProbationDeadline
+	}
+
+	if probation.Interval <= 0 {
+		probation.Interval = config.DefaultProbationInterval
+	}
+}
+
+// startProbation puts a freshly activated version on probation. It is
+// recorded before the restart so the new process picks it up.
+func (s *Supervisor) startProbation(version string) error {
+	if s.config.Probation == nil {
+		return nil
+	}
+
+	return s.updateState(func(st *state) {
+		st.Probation = &probationState{
+			Version: version,
+			Started: time.Now(),
+		}
+	})
+}
+
+// MarkReady records that the child reported itself healthy, ending an
+// ongoing probation successfully.
+func (s *Supervisor) MarkReady() {
+	s.readyOnce.Do(func() {
+		close(s.ready)
+	})
+}
+
+// watchProbation waits for the active version to prove it is healthy if it
+// is on probation. If neither the child reports ready nor a configured probe
+// succeeds before the deadline, the version is marked as failed and rolled
+// back.
+func (s *Supervisor) watchProbation(ctx context.Context) {
+	st, err := s.readState()
+	if err != nil {
+		slog.Error("failed to read supervisor state", "error", err)
+		return
+	}
+
+	if st.Probation == nil {
+		return
+	}
+
+	probation := st.Probation
+	active, err := s.activeVersion()
+
+	if err != nil || active != probation.Version {
+		// The version on probation is no longer active, e.g. after a rollback
+		s.endProbation()
+		return
+	}
+
+	cfg := s.config.Probation
+	deadline := probation.Started.Add(cfg.Deadline)
+
+	slog.Info("version on probation", "version", probation.Version, "deadline", deadline)
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ready:
+			slog.Info("version passed probation, reported ready", "version", probation.Version)
+			s.endProbation()
+			return
+
+		case <-ticker.C:
+			if err := s.probe(ctx); err != nil {
+				slog.Debug("probation probe failed", "version", probation.Version, "error", err)
+				continue
+			}
+
+			slog.Info("version passed probation, probe succeeded", "version", probation.Version)
+			s.endProbation()
+			return
+
+		case <-ctx.Done():
+			s.failProbation(probation.Version)
+			return
+		}
+	}
+}
+
+func (s *Supervisor) endProbation() {
+	err := s.updateState(func(st *state) {
+		st.Probation = nil
+	})
+
+	if err != nil {
+		slog.Error("failed to clear probation", "error", err)
+	}
+}
+
+func (s *Supervisor) failProbation(version string) {
+	slog.Error("version failed probation, initiating rollback", "version", version, "deadline", s.config.Probation.Deadline)
+
+	s.endProbation()
+	s.quarantine(version, fmt.Sprintf("failed probation: not healthy within %s", s.config.Probation.Deadline))
+
+	if err := s.rollback(TriggerProbationFail); err != nil {
+		slog.Error("Rollback failed", "error", err)
+	}
+}
+
+// probe runs the configured HTTP and exec probes. Without configured probes
+// only a ready report from the child ends the probation.
+func (s *Supervisor) probe(ctx context.Context) error {
+	cfg := s.config.Probation
+
+	if cfg.HTTPProbe == "" && len(cfg.ExecProbe) == 0 {
+		return fmt.Errorf("waiting for ready report")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Interval)
+	defer cancel()
+
+	if cfg.HTTPProbe != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.HTTPProbe, nil)
+		if err != nil {
+			return fmt.Errorf("invalid http probe: %w", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("http probe failed: %w", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("http probe returned status %d", resp.StatusCode)
+		}
+	}
+
+	if len(cfg.ExecProbe) > 0 {
+		if err := exec.CommandContext(ctx, cfg.ExecProbe[0], cfg.ExecProbe[1:]...).Run(); err != nil {
+			return fmt.Errorf("exec probe failed: %w", err)
+		}
+	}
+
+	return nil
+}
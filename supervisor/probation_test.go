@@ -0,0 +1,23 @@
+package supervisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+func TestProbationDefaults(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource(), func(cfg *config.Config) {
+		// Configured without WithProbation
+		cfg.Probation = &config.ProbationConfig{Deadline: time.Minute}
+	})
+
+	if got := s.config.Probation.Deadline; got != time.Minute {
+		t.Errorf("Deadline = %s, want the configured 1m", got)
+	}
+
+	if got := s.config.Probation.Interval; got != config.DefaultProbationInterval {
+		t.Errorf("Interval = %s, want the default %s", got, config.DefaultProbationInterval)
+	}
+}
@@ -0,0 +1,138 @@
+package supervisor
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// QuarantinedVersion is a version that is no longer installed automatically
+// because it crash-looped or failed its probation.
+type QuarantinedVersion struct {
+	Version string    `json:"version"`
+	Reason  string    `json:"reason"`
+	Since   time.Time `json:"since"`
+}
+
+// quarantine adds a version to the quarantine set.
+func (s *Supervisor) quarantine(version, reason string) {
+	version = canonicalVersion(version)
+
+	slog.Warn("quarantining version", "version", version, "reason", reason)
+
+	err := s.updateState(func(st *state) {
+		if st.Quarantine == nil {
+			st.Quarantine = map[string]QuarantinedVersion{}
+		}
+
+		st.Quarantine[version] = QuarantinedVersion{
+			Version: version,
+			Reason:  reason,
+			Since:   time.Now(),
+		}
+	})
+
+	if err != nil {
+		slog.Error("failed to quarantine version", "version", version, "error", err)
+	}
+}
+
+// Quarantine returns all quarantined versions, oldest first.
+func (s *Supervisor) Quarantine() ([]QuarantinedVersion, error) {
+	st, err := s.readState()
+	if err != nil {
+		return nil, err
+	}
+
+	quarantine := make([]QuarantinedVersion, 0, len(st.Quarantine))
+
+	for _, q := range st.Quarantine {
+		quarantine = append(quarantine, q)
+	}
+
+	sort.Slice(quarantine, func(i, j int) bool {
+		return quarantine[i].Since.Before(quarantine[j].Since)
+	})
+
+	return quarantine, nil
+}
+
+// ClearQuarantine removes a version from the quarantine set. An empty
+// version clears all entries.
+func (s *Supervisor) ClearQuarantine(version string) error {
+	var notFound bool
+
+	err := s.updateState(func(st *state) {
+		if version == "" {
+			st.Quarantine = nil
+			return
+		}
+
+		key, ok := quarantineKey(st, version)
+		if !ok {
+			notFound = true
+			return
+		}
+
+		delete(st.Quarantine, key)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if notFound {
+		return fmt.Errorf("version %s is not quarantined", version)
+	}
+
+	slog.Info("cleared quarantine", "version", version)
+
+	return nil
+}
+
+// quarantined returns the quarantine entry of a version, if any.
+func (s *Supervisor) quarantined(version string) (*QuarantinedVersion, error) {
+	st, err := s.readState()
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := quarantineKey(st, version); ok {
+		q := st.Quarantine[key]
+		return &q, nil
+	}
+
+	return nil, nil
+}
+
+// quarantineKey returns the key of the quarantine entry for any spelling of
+// version. Entries recorded by older releases may use the tag's spelling.
+func quarantineKey(st *state, version string) (string, bool) {
+	version = canonicalVersion(version)
+
+	for key := range st.Quarantine {
+		if canonicalVersion(key) == version {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+// withoutQuarantined filters quarantined versions from the set.
+func (s *Supervisor) withoutQuarantined(versions release.VersionSet) (release.VersionSet, error) {
+	st, err := s.readState()
+	if err != nil {
+		return release.VersionSet{}, err
+	}
+
+	return versions.Filter(func(v *semver.Version) bool {
+		_, quarantined := quarantineKey(st, v.String())
+
+		return !quarantined
+	}), nil
+}
@@ -0,0 +1,131 @@
+package supervisor
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+// listenerEnv carries the file descriptor of the IPC socket across an
+// in-place re-exec of the supervisor.
+const listenerEnv = "KNOCKKNOCK_LISTENER_FD"
+
+// Listen returns the unix socket listener for the IPC server. After an
+// in-place restart the listener inherited from the previous process image
+// is reused, so the socket stays reachable throughout the restart.
+func (s *Supervisor) Listen() (net.Listener, error) {
+	if fd := os.Getenv(listenerEnv); fd != "" {
+		os.Unsetenv(listenerEnv)
+
+		listener, err := inheritListener(fd)
+		if err != nil {
+			return nil, err
+		}
+
+		s.listener = listener
+		return listener, nil
+	}
+
+	// Clean up old socket if exists
+	os.Remove(s.socketPath)
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unix socket: %w", err)
+	}
+
+	s.listener = listener
+	return listener, nil
+}
+
+func inheritListener(fd string) (net.Listener, error) {
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inherited listener fd '%s': %w", fd, err)
+	}
+
+	file := os.NewFile(uintptr(n), "knockknock-ipc")
+	defer file.Close()
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inherit ipc listener: %w", err)
+	}
+
+	return listener, nil
+}
+
+// beginChange acquires the version change lock, failing if another update,
+// rollback or activation is already in progress.
+func (s *Supervisor) beginChange() error {
+	if !s.changeMu.TryLock() {
+		return fmt.Errorf("another version change is already in progress")
+	}
+
+	return nil
+}
+
+// restart brings up the newly activated version according to the configured
+// restart mode. It must be called with changeMu held, which is released if
+// the restart fails.
+func (s *Supervisor) restart() error {
+	var err error
+
+	if s.config.RestartMode == config.RestartModeExec {
+		err = s.reexec()
+	} else if killErr := syscall.Kill(os.Getpid(), syscall.SIGTERM); killErr != nil {
+		// Kill the current process - systemd will restart it with the new version
+		err = fmt.Errorf("failed to send termination signal: %w", killErr)
+	}
+
+	if err != nil {
+		s.changeMu.Unlock()
+	}
+
+	return err
+}
+
+// reexec stops the child and replaces the supervisor process image with the
+// current binary. PID, arguments and environment are kept and the IPC
+// listener is handed over as an inherited file descriptor.
+func (s *Supervisor) reexec() error {
+	binary := filepath.Join(s.dataDir, "current", s.config.BinaryName)
+
+	if err := verifyBinary(binary); err != nil {
+		return fmt.Errorf("refusing to exec into invalid binary: %w", err)
+	}
+
+	env := os.Environ()
+
+	if listener, ok := s.listener.(*net.UnixListener); ok {
+		file, err := listener.File()
+		if err != nil {
+			return fmt.Errorf("failed to get ipc listener fd: %w", err)
+		}
+
+		// File() returns a close-on-exec duplicate, clear the flag so the
+		// descriptor survives exec
+		if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, file.Fd(), syscall.F_SETFD, 0); errno != 0 {
+			return fmt.Errorf("failed to clear close-on-exec on ipc listener: %w", errno)
+		}
+
+		env = append(env, fmt.Sprintf("%s=%d", listenerEnv, file.Fd()))
+	}
+
+	s.stopChild()
+
+	slog.Info("re-executing supervisor", "binary", binary, "pid", os.Getpid())
+
+	err := syscall.Exec(binary, os.Args, env)
+
+	// Exec only returns on failure, bring the child back up
+	s.resumeChild()
+
+	return fmt.Errorf("failed to exec %s: %w", binary, err)
+}
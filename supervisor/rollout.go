@@ -0,0 +1,133 @@
+package supervisor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// machineIDPaths are the locations of the systemd and D-Bus machine IDs.
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// rolloutSeed returns the seed the host's rollout bucket is derived from:
+// the configured seed, the machine ID or the hostname.
+func rolloutSeed(cfg *config.Config) (string, error) {
+	if cfg.RolloutSeed != "" {
+		return cfg.RolloutSeed, nil
+	}
+
+	for _, path := range machineIDPaths {
+		if id, err := os.ReadFile(path); err == nil && len(strings.TrimSpace(string(id))) > 0 {
+			return strings.TrimSpace(string(id)), nil
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine rollout seed, configure one with WithRolloutSeed: %w", err)
+	}
+
+	return hostname, nil
+}
+
+// rolloutBucket returns the host's bucket in [0, 100) for a version. The
+// version is part of the hash so that not the same hosts are always the
+// first to receive a release.
+func (s *Supervisor) rolloutBucket(version string) int {
+	sum := sha256.Sum256([]byte(s.rolloutSeed + "/" + version))
+
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
+
+// rolledOut reports whether the version's phased rollout includes this host.
+func (s *Supervisor) rolledOut(ctx context.Context, version *semver.Version) (bool, error) {
+	percentage, err := s.rolloutPercentage(ctx, version)
+	if err != nil {
+		return false, err
+	}
+
+	bucket := s.rolloutBucket(version.String())
+
+	if bucket >= percentage {
+		slog.Debug("version not yet rolled out to this host", "version", version, "rollout", percentage, "bucket", bucket)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// rolloutPercentage returns the rollout percentage of a version. Resolving
+// a release can be expensive, e.g. hashing a release directory, so it is
+// done once per listing of the versions: percentages are only resolved
+// again once the version list was refreshed. If the source fails, the last
+// known percentage is used, and versions never resolved are held back.
+func (s *Supervisor) rolloutPercentage(ctx context.Context, version *semver.Version) (int, error) {
+	c := &s.versionCache
+
+	c.mu.Lock()
+	cached, ok := c.rollouts[version.String()]
+	generation := c.generation
+	c.mu.Unlock()
+
+	if ok && cached.generation == generation {
+		return cached.percentage, nil
+	}
+
+	var percentage int
+
+	artifact, err := s.source.Resolve(ctx, version.Original())
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return 0, err
+	case err != nil && ok:
+		slog.Warn("failed to resolve version, using its last known rollout", "version", version, "rollout", cached.percentage, "error", err)
+		percentage = cached.percentage
+	case err != nil:
+		slog.Warn("failed to resolve version, holding it back", "version", version, "error", err)
+	default:
+		if percentage, err = artifact.Rollout(); err != nil {
+			// Hold back rather than offer a release to the whole fleet
+			slog.Warn("skipping version with invalid rollout", "version", version, "error", err)
+			percentage = 0
+		}
+	}
+
+	// Failures are not retried before the next refresh either, so that an
+	// unavailable source isn't asked on every check
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rollouts == nil {
+		c.rollouts = map[string]rollout{}
+	}
+
+	c.rollouts[version.String()] = rollout{percentage: percentage, generation: generation}
+
+	return percentage, nil
+}
+
+// newestRolledOut returns the highest version newer than the current version
+// whose phased rollout includes this host, or nil if there is none.
+func (s *Supervisor) newestRolledOut(ctx context.Context, versions release.VersionSet) (*semver.Version, error) {
+	for v := versions.Latest(); v != nil && v.GreaterThan(s.currentVersion); v = versions.Previous(v) {
+		ok, err := s.rolledOut(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return v, nil
+		}
+	}
+
+	return nil, nil
+}
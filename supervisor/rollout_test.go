@@ -0,0 +1,121 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/zeitlos/knockknock/release"
+)
+
+func TestRolloutResolvedOncePerVersionList(t *testing.T) {
+	source := newFakeSource()
+	binary := elfBinary(t)
+
+	source.add("1.1.0", nil, map[string][]byte{"myapp": binary})
+	source.add("1.2.0", map[string]string{release.RolloutAnnotation: "0"}, map[string][]byte{"myapp": append(binary, 2)})
+	source.add("1.3.0", map[string]string{release.RolloutAnnotation: "many"}, map[string][]byte{"myapp": append(binary, 3)})
+
+	s := newTestSupervisor(t, source)
+	ctx := context.Background()
+
+	check := func() {
+		t.Helper()
+
+		update, _, err := s.CheckForUpdate(ctx)
+		if err != nil {
+			t.Fatalf("CheckForUpdate() error = %v", err)
+		}
+
+		// 1.3.0 has an invalid rollout and 1.2.0 is rolled out to nobody
+		if update == nil || update.String() != "1.1.0" {
+			t.Fatalf("CheckForUpdate() = %v, want 1.1.0", update)
+		}
+	}
+
+	check()
+
+	resolves := source.resolveCount()
+	if resolves != 3 {
+		t.Fatalf("first check resolved %d versions, want 3", resolves)
+	}
+
+	for range 5 {
+		check()
+	}
+
+	if got := source.resolveCount(); got != resolves {
+		t.Errorf("cached checks resolved %d more versions, want none", got-resolves)
+	}
+
+	if err := s.RefreshVersions(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	check()
+
+	if got := source.resolveCount(); got != 2*resolves {
+		t.Errorf("check after a refresh resolved %d versions, want %d", got-resolves, resolves)
+	}
+}
+
+func TestRolloutServedWhenResolveFails(t *testing.T) {
+	source := newFakeSource()
+	binary := elfBinary(t)
+
+	source.add("1.1.0", nil, map[string][]byte{"myapp": binary})
+	source.add("1.2.0", map[string]string{release.RolloutAnnotation: "0"}, map[string][]byte{"myapp": append(binary, 2)})
+
+	s := newTestSupervisor(t, source)
+	ctx := context.Background()
+
+	check := func(want string) {
+		t.Helper()
+
+		update, versions, err := s.CheckForUpdate(ctx)
+		if err != nil {
+			t.Fatalf("CheckForUpdate() error = %v", err)
+		}
+
+		if len(versions) != 2 {
+			t.Errorf("CheckForUpdate() listed %d versions, want 2", len(versions))
+		}
+
+		if update == nil || update.String() != want {
+			t.Fatalf("CheckForUpdate() = %v, want %s", update, want)
+		}
+	}
+
+	check("1.1.0")
+
+	// The registry goes down after the version list was refreshed
+	if err := s.RefreshVersions(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	source.mu.Lock()
+	source.resolveErr = errors.New("registry down")
+	source.mu.Unlock()
+
+	check("1.1.0")
+
+	resolves := source.resolveCount()
+
+	check("1.1.0")
+
+	if got := source.resolveCount(); got != resolves {
+		t.Errorf("checks while resolving fails resolved %d more versions, want none", got-resolves)
+	}
+
+	// Without a known rollout versions are held back
+	fresh := newTestSupervisor(t, source)
+
+	update, _, err := fresh.CheckForUpdate(ctx)
+	if err != nil {
+		t.Fatalf("CheckForUpdate() without known rollouts error = %v", err)
+	}
+
+	if update != nil {
+		t.Errorf("CheckForUpdate() without known rollouts = %s, want nil", update)
+	}
+}
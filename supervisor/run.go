@@ -1,12 +1,18 @@
 package supervisor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
+
+	"github.com/zeitlos/knockknock/config"
 )
 
 func (s *Supervisor) Run() {
@@ -14,6 +20,22 @@ func (s *Supervisor) Run() {
 	crashCount := 0
 	resetWindow := time.NewTicker(5 * time.Minute)
 
+	go s.handleSignals()
+
+	if err := s.seedJournal(); err != nil {
+		slog.Warn("failed to seed update journal", "error", err)
+	}
+
+	s.verifyInstalled()
+
+	if s.config.Probation != nil {
+		go s.watchProbation(context.Background())
+	}
+
+	if s.config.AutoUpdate != nil && s.config.AutoUpdate.Enabled {
+		go s.autoUpdate(context.Background())
+	}
+
 	for {
 		select {
 		case <-resetWindow.C:
@@ -21,6 +43,16 @@ func (s *Supervisor) Run() {
 		default:
 		}
 
+		s.mu.Lock()
+
+		// The child was stopped on purpose (e.g. for a restart), wait until
+		// it may run again
+		if paused := s.paused; paused != nil {
+			s.mu.Unlock()
+			<-paused
+			continue
+		}
+
 		// Launch child process
 		cmd := exec.Command(os.Args[0], os.Args[1:]...)
 		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", socketEnv, socketPath))
@@ -32,10 +64,29 @@ func (s *Supervisor) Run() {
 			panic(err)
 		}
 
-		exitCode := 0
+		c := &child{
+			cmd:  cmd,
+			done: make(chan struct{}),
+		}
+		s.child = c
+		s.mu.Unlock()
 
 		// Wait for child to exit
-		if err := cmd.Wait(); err != nil {
+		err := cmd.Wait()
+
+		s.mu.Lock()
+		s.child = nil
+		stopped := s.paused != nil
+		s.mu.Unlock()
+		close(c.done)
+
+		if stopped {
+			continue
+		}
+
+		exitCode := 0
+
+		if err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
 					exitCode = status.ExitStatus()
@@ -59,7 +110,11 @@ func (s *Supervisor) Run() {
 		if crashCount >= 3 {
 			slog.Error("Too many crashes, initiating rollback")
 
-			if err := s.Rollback(); err != nil {
+			if active, err := s.activeVersion(); err == nil {
+				s.quarantine(active, fmt.Sprintf("crash loop: %d crashes", crashCount))
+			}
+
+			if err := s.rollback(TriggerCrashRollback); err != nil {
 				slog.Error("Rollback failed", "error", err)
 			}
 
@@ -70,6 +125,110 @@ func (s *Supervisor) Run() {
 	}
 }
 
+// child is a running child process. done is closed once it has been reaped.
+type child struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// defaultShutdown applies the defaults of config.New to shutdown settings
+// left zero, e.g. in Config literals written before they existed.
+func defaultShutdown(cfg *config.Config) {
+	if cfg.ShutdownSignal == 0 {
+		cfg.ShutdownSignal = syscall.SIGTERM
+	}
+
+	if cfg.ShutdownGracePeriod <= 0 {
+		cfg.ShutdownGracePeriod = config.DefaultShutdownGracePeriod
+	}
+}
+
+// stopChild asks the running child to shut down with the configured signal
+// and kills it if it has not exited within the grace period. Run will not
+// start a new child until resumeChild is called.
+func (s *Supervisor) stopChild() {
+	s.mu.Lock()
+	c := s.child
+
+	if s.paused == nil {
+		s.paused = make(chan struct{})
+	}
+	s.mu.Unlock()
+
+	if c == nil {
+		return
+	}
+
+	slog.Info("stopping child", "pid", c.cmd.Process.Pid, "signal", s.config.ShutdownSignal, "gracePeriod", s.config.ShutdownGracePeriod)
+
+	if err := c.cmd.Process.Signal(s.config.ShutdownSignal); err != nil {
+		slog.Warn("failed to signal child", "error", err)
+	}
+
+	timer := time.NewTimer(s.config.ShutdownGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-c.done:
+		return
+	case <-timer.C:
+	}
+
+	slog.Warn("child did not exit within grace period, killing it", "pid", c.cmd.Process.Pid)
+
+	if err := c.cmd.Process.Kill(); err != nil {
+		slog.Warn("failed to kill child", "error", err)
+	}
+
+	<-c.done
+}
+
+// handleSignals shuts the child down gracefully before the supervisor exits
+// when it is asked to terminate, e.g. by the process manager or after an
+// update in RestartModeSignal.
+func (s *Supervisor) handleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-signals
+	slog.Info("supervisor received signal, shutting down", "signal", sig)
+
+	s.stopChild()
+	os.Exit(0)
+}
+
+// resumeChild lets Run start the child again after stopChild.
+func (s *Supervisor) resumeChild() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused != nil {
+		close(s.paused)
+		s.paused = nil
+	}
+}
+
+// verifyInstalled re-checks the active version against its recorded digests
+// on startup and rolls back if the binary on disk was modified.
+func (s *Supervisor) verifyInstalled() {
+	if _, err := os.Lstat(filepath.Join(s.dataDir, "current")); err != nil {
+		// Not installed through knockknock yet, nothing to verify
+		return
+	}
+
+	err := s.Verify("")
+
+	if err == nil || errors.Is(err, ErrNoMetadata) {
+		return
+	}
+
+	slog.Error("active version failed integrity verification, initiating rollback", "error", err)
+
+	if err := s.rollback(TriggerIntegrityFail); err != nil {
+		slog.Error("Rollback failed", "error", err)
+	}
+}
+
 func IsSupervisorProcess() bool {
 	return os.Getenv(socketEnv) == ""
 }
@@ -0,0 +1,24 @@
+package supervisor
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/zeitlos/knockknock/config"
+)
+
+func TestShutdownDefaults(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource(), func(cfg *config.Config) {
+		// Config literals written before the shutdown settings existed
+		cfg.ShutdownSignal = 0
+		cfg.ShutdownGracePeriod = 0
+	})
+
+	if got := s.config.ShutdownSignal; got != syscall.SIGTERM {
+		t.Errorf("ShutdownSignal = %v, want SIGTERM", got)
+	}
+
+	if got := s.config.ShutdownGracePeriod; got != config.DefaultShutdownGracePeriod {
+		t.Errorf("ShutdownGracePeriod = %s, want %s", got, config.DefaultShutdownGracePeriod)
+	}
+}
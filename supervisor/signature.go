@@ -0,0 +1,102 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// validateSigningKeys ensures all configured keys are of a supported type.
+func validateSigningKeys(keys []crypto.PublicKey) error {
+	for i, key := range keys {
+		switch key.(type) {
+		case ed25519.PublicKey, *ecdsa.PublicKey:
+		default:
+			return fmt.Errorf("signing key %d has unsupported type %T, expected ed25519 or ecdsa", i, key)
+		}
+	}
+
+	return nil
+}
+
+// verifySignature checks that the artifact carries at least one signature
+// made by a configured signing key. Signatures are made over the SHA-256
+// digest of the artifact's file listing, see signedListing, so they cover
+// every file of the release. Releases of a single file may instead be signed
+// over the SHA-256 digest of that file. The files must have been verified
+// against their digests before. Verification is skipped when no signing keys
+// are configured.
+func (s *Supervisor) verifySignature(ctx context.Context, artifact *release.Artifact) error {
+	if len(s.config.SigningKeys) == 0 {
+		return nil
+	}
+
+	signatures, err := release.Signatures(ctx, s.source, artifact)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signatures: %w", err)
+	}
+
+	if len(signatures) == 0 {
+		return fmt.Errorf("%s is not signed", artifact.Reference)
+	}
+
+	listing := sha256.Sum256(signedListing(artifact.Files))
+	digests := [][]byte{listing[:]}
+
+	if len(artifact.Files) == 1 && artifact.Files[0].Digest.Algorithm() == digest.SHA256 {
+		if sum, err := hex.DecodeString(artifact.Files[0].Digest.Encoded()); err == nil {
+			digests = append(digests, sum)
+		}
+	}
+
+	for _, signature := range signatures {
+		for _, key := range s.config.SigningKeys {
+			for _, sum := range digests {
+				if verifyDigest(key, sum, signature) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("no valid signature found for %s", artifact.Reference)
+}
+
+// signedListing returns the file listing signatures are made over, in the
+// format of sha256sum sorted by path: one "<digest>  <path>" line per file.
+func signedListing(files []release.File) []byte {
+	sorted := slices.Clone(files)
+
+	slices.SortFunc(sorted, func(a, b release.File) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	var listing bytes.Buffer
+
+	for _, file := range sorted {
+		fmt.Fprintf(&listing, "%s  %s\n", file.Digest.Encoded(), file.Path)
+	}
+
+	return listing.Bytes()
+}
+
+func verifyDigest(key crypto.PublicKey, digest, signature []byte) bool {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, digest, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(k, digest, signature)
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+package supervisor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/zeitlos/knockknock/config"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// sha256sum returns a line of sha256sum output for data.
+func sha256sum(path string, data []byte) string {
+	return fmt.Sprintf("%x  %s\n", sha256.Sum256(data), path)
+}
+
+func TestVerifySignature(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binary := elfBinary(t)
+	plugin := []byte("plugin")
+	tampered := []byte("tampered")
+
+	both := map[string][]byte{"myapp": binary, "lib/plugin.so": plugin}
+	listing := sha256sum("lib/plugin.so", plugin) + sha256sum("myapp", binary)
+
+	ed25519Sign := func(key ed25519.PrivateKey, message string) []byte {
+		sum := sha256.Sum256([]byte(message))
+		return ed25519.Sign(key, sum[:])
+	}
+
+	tests := []struct {
+		name      string
+		files     map[string][]byte
+		signature []byte
+		wantErr   bool
+	}{
+		{
+			name:      "listing signed",
+			files:     both,
+			signature: ed25519Sign(key, listing),
+		},
+		{
+			name:  "listing signed with ecdsa",
+			files: both,
+			signature: func() []byte {
+				sum := sha256.Sum256([]byte(listing))
+
+				signature, err := ecdsa.SignASN1(rand.Reader, ecKey, sum[:])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return signature
+			}(),
+		},
+		{
+			name:      "single binary signed",
+			files:     map[string][]byte{"myapp": binary},
+			signature: ed25519Sign(key, string(binary)),
+		},
+		{
+			name:      "only the binary signed",
+			files:     both,
+			signature: ed25519Sign(key, string(binary)),
+			wantErr:   true,
+		},
+		{
+			name:      "other file swapped",
+			files:     map[string][]byte{"myapp": binary, "lib/plugin.so": tampered},
+			signature: ed25519Sign(key, listing),
+			wantErr:   true,
+		},
+		{
+			name:      "file added",
+			files:     map[string][]byte{"myapp": binary, "lib/plugin.so": plugin, "lib/extra.so": tampered},
+			signature: ed25519Sign(key, listing),
+			wantErr:   true,
+		},
+		{
+			name:      "unknown key",
+			files:     both,
+			signature: ed25519Sign(otherKey, listing),
+			wantErr:   true,
+		},
+		{
+			name:    "unsigned",
+			files:   both,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var annotations map[string]string
+
+			if tt.signature != nil {
+				annotations = map[string]string{release.SignatureAnnotation: base64.StdEncoding.EncodeToString(tt.signature)}
+			}
+
+			source := newFakeSource()
+			source.add("2.0.0", annotations, tt.files)
+
+			s := newTestSupervisor(t, source, func(c *config.Config) {
+				c.WithSigningKeys(key.Public(), &ecKey.PublicKey)
+			})
+
+			_, err := s.Stage(context.Background(), "2.0.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Stage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureOfDownloadedVersion(t *testing.T) {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := newFakeSource()
+	source.add("2.0.0", nil, map[string][]byte{"myapp": elfBinary(t)})
+
+	s := newTestSupervisor(t, source)
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+		t.Fatalf("Stage() without signing keys error = %v", err)
+	}
+
+	s.config.WithSigningKeys(public)
+
+	if _, err := s.Stage(context.Background(), "2.0.0"); err == nil {
+		t.Error("Stage() of the downloaded unsigned version succeeded after configuring signing keys")
+	}
+}
@@ -0,0 +1,105 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/zeitlos/knockknock/release"
+)
+
+// Stage downloads and verifies a version into the versions directory without
+// activating it, so that a later Activate only has to swap the symlink and
+// restart. The reference takes the same forms as for Update. It returns the
+// staged version.
+func (s *Supervisor) Stage(ctx context.Context, reference string) (string, error) {
+	return s.stage(ctx, reference, TriggerManual, false)
+}
+
+// ForceStage is like Stage but also stages quarantined versions and versions
+// rejected by the configured constraint.
+func (s *Supervisor) ForceStage(ctx context.Context, reference string) (string, error) {
+	return s.stage(ctx, reference, TriggerManual, true)
+}
+
+// stage downloads the referenced version and records the outcome in the
+// journal. Staging holds the change lock so that it cannot race with an
+// update writing to the same version directory.
+func (s *Supervisor) stage(ctx context.Context, reference string, trigger Trigger, force bool) (string, error) {
+	if err := s.beginChange(); err != nil {
+		return "", err
+	}
+	defer s.changeMu.Unlock()
+
+	s.setStaging(reference)
+	defer s.setStaging("")
+
+	entry := s.newJournalEntry(ActionStage, trigger)
+	entry.To = reference
+
+	version, err := s.download(ctx, reference, entry, force)
+	s.record(entry, err)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to stage %s: %w", reference, err)
+	}
+
+	slog.Info("staged version", "version", version)
+
+	return version, nil
+}
+
+func (s *Supervisor) setStaging(reference string) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	s.staging = reference
+}
+
+// Staging returns the reference currently being staged, or an empty string.
+func (s *Supervisor) Staging() string {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	return s.staging
+}
+
+// downloadOptions configures downloads to resume from and report progress
+// through the supervisor.
+func (s *Supervisor) downloadOptions() release.DownloadOptions {
+	return release.DownloadOptions{
+		TempDir:   filepath.Join(s.dataDir, "downloads"),
+		RateLimit: s.config.DownloadRateLimit,
+		Progress:  s.setProgress,
+	}
+}
+
+func (s *Supervisor) setProgress(progress release.Progress) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	s.progress = &progress
+}
+
+// Progress returns the state of the running download, or nil if nothing is
+// being downloaded.
+func (s *Supervisor) Progress() *release.Progress {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	if s.progress == nil {
+		return nil
+	}
+
+	progress := *s.progress
+
+	return &progress
+}
+
+func (s *Supervisor) clearProgress() {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	s.progress = nil
+}
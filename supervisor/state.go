@@ -0,0 +1,144 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// state is the supervisor state persisted across restarts in
+// <dataDir>/state.json.
+type state struct {
+	// Probation is set while a freshly activated version has not yet
+	// proven to be healthy
+	Probation *probationState `json:"probation,omitempty"`
+
+	// Channel is the release channel selected at runtime, overriding the
+	// configured one
+	Channel string `json:"channel,omitempty"`
+
+	// Quarantine holds versions that must not be installed again unless
+	// forced, keyed by version
+	Quarantine map[string]QuarantinedVersion `json:"quarantine,omitempty"`
+
+	// Failed holds the versions that failed their probation as recorded by
+	// earlier releases. It is only read to migrate them into Quarantine.
+	Failed map[string]failedVersion `json:"failed,omitempty"`
+
+	// MaintenanceOverride allows automatic updates outside of maintenance
+	// windows until the given time
+	MaintenanceOverride time.Time `json:"maintenance_override,omitzero"`
+}
+
+type probationState struct {
+	Version string    `json:"version"`
+	Started time.Time `json:"started"`
+}
+
+type failedVersion struct {
+	Reason string    `json:"reason"`
+	Failed time.Time `json:"failed"`
+}
+
+func (s *Supervisor) statePath() string {
+	return filepath.Join(s.dataDir, "state.json")
+}
+
+// loadState reads the persisted state. A missing state file yields an empty
+// state. Callers must hold stateMu.
+func (s *Supervisor) loadState() (*state, error) {
+	st := &state{}
+
+	data, err := os.ReadFile(s.statePath())
+
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	migrateFailed(st)
+
+	return st, nil
+}
+
+// migrateFailed moves versions recorded as failed by earlier releases into
+// the quarantine. Entries already quarantined take precedence.
+func migrateFailed(st *state) {
+	for version, failed := range st.Failed {
+		if _, ok := quarantineKey(st, version); ok {
+			continue
+		}
+
+		if st.Quarantine == nil {
+			st.Quarantine = map[string]QuarantinedVersion{}
+		}
+
+		st.Quarantine[version] = QuarantinedVersion{
+			Version: version,
+			Reason:  failed.Reason,
+			Since:   failed.Failed,
+		}
+	}
+
+	st.Failed = nil
+}
+
+// saveState atomically replaces the persisted state. Callers must hold
+// stateMu.
+func (s *Supervisor) saveState(st *state) error {
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	path := s.statePath()
+	tempPath := fmt.Sprintf("%s.tmp.%d", path, time.Now().UnixNano())
+
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move state into place: %w", err)
+	}
+
+	return nil
+}
+
+// updateState loads the state, applies fn and saves the result.
+func (s *Supervisor) updateState(fn func(st *state)) error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	st, err := s.loadState()
+	if err != nil {
+		return err
+	}
+
+	fn(st)
+
+	return s.saveState(st)
+}
+
+// readState returns a snapshot of the persisted state.
+func (s *Supervisor) readState() (*state, error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	return s.loadState()
+}
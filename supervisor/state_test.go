@@ -0,0 +1,52 @@
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedVersionsAreQuarantined(t *testing.T) {
+	s := newTestSupervisor(t, newFakeSource())
+
+	if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// State written by earlier releases
+	data := `{
+  "failed": {
+    "1.2.0": {"reason": "probation deadline exceeded", "failed": "2026-01-02T12:00:00Z"},
+    "1.3.0": {"reason": "probation deadline exceeded", "failed": "2026-01-03T12:00:00Z"}
+  },
+  "quarantine": {
+    "1.3.0": {"version": "1.3.0", "reason": "crash loop", "since": "2026-01-04T12:00:00Z"}
+  }
+}`
+
+	if err := os.WriteFile(filepath.Join(s.dataDir, "state.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := s.quarantined("1.2.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q == nil || q.Reason != "probation deadline exceeded" || q.Since.IsZero() {
+		t.Errorf("quarantined(1.2.0) = %+v, want the failed version", q)
+	}
+
+	if q, _ := s.quarantined("1.3.0"); q == nil || q.Reason != "crash loop" {
+		t.Errorf("quarantined(1.3.0) = %+v, want the quarantine entry", q)
+	}
+
+	// The migration is persisted with the next change
+	if err := s.ClearQuarantine("1.3.0"); err != nil {
+		t.Fatal(err)
+	}
+
+	if q, _ := s.quarantined("1.2.0"); q == nil {
+		t.Error("quarantined(1.2.0) = nil after saving the state")
+	}
+}
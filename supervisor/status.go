@@ -0,0 +1,169 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/oras"
+	"github.com/zeitlos/knockknock/release"
+)
+
+// Status describes the installed versions and ongoing operations.
+type Status struct {
+	// Active is the version the current symlink points to, empty if no
+	// version was activated yet, e.g. on fresh or legacy installations
+	Active string
+
+	// Channel is the release channel updates are taken from
+	Channel string
+
+	// Staging is the reference currently being staged, if any
+	Staging string
+
+	// Download is the progress of the running download, if any
+	Download *release.Progress
+
+	// Maintenance is the state of the maintenance windows, nil if updates
+	// are not restricted to maintenance windows
+	Maintenance *MaintenanceStatus
+
+	// Mirrors is the health of the repository and its mirrors, nil if no
+	// mirrors are configured
+	Mirrors []oras.MirrorStatus
+
+	// Installed lists all versions in the versions directory in ascending
+	// order
+	Installed []InstalledVersion
+}
+
+// InstalledVersion is a version present in the versions directory.
+type InstalledVersion struct {
+	Version     string
+	Digest      string
+	InstalledAt time.Time
+	Active      bool
+
+	// Staged is set for versions newer than the active version, or the
+	// running version if none was activated yet, i.e. versions that were
+	// downloaded ahead of time and can be activated
+	Staged bool
+}
+
+// Status returns the installed versions and ongoing operations.
+func (s *Supervisor) Status() (*Status, error) {
+	active, err := s.activeVersion()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	installed, err := s.installedVersions(active)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Active:      active,
+		Channel:     s.Channel().Name,
+		Staging:     s.Staging(),
+		Download:    s.Progress(),
+		Maintenance: s.Maintenance(),
+		Installed:   installed,
+	}
+
+	if mirrors, ok := s.source.(*oras.MirrorSource); ok {
+		status.Mirrors = mirrors.Status()
+	}
+
+	return status, nil
+}
+
+// StagedVersions returns the installed versions newer than the active
+// version.
+func (s *Supervisor) StagedVersions() ([]InstalledVersion, error) {
+	status, err := s.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(status.Installed, func(v InstalledVersion) bool {
+		return !v.Staged
+	}), nil
+}
+
+func (s *Supervisor) installedVersions(active string) ([]InstalledVersion, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dataDir, "versions"))
+	if errors.Is(err, os.ErrNotExist) {
+		// Nothing was downloaded yet
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions directory: %w", err)
+	}
+
+	// Without an active version, e.g. on fresh and legacy installations,
+	// versions are staged relative to the running one
+	base := s.currentVersion
+	if v, err := semver.NewVersion(active); err == nil {
+		base = v
+	}
+
+	var installed []InstalledVersion
+
+	for _, entry := range entries {
+		// Skip metadata files
+		if !entry.IsDir() {
+			continue
+		}
+
+		version := InstalledVersion{
+			Version: entry.Name(),
+			Active:  entry.Name() == active,
+		}
+
+		if metadata, err := s.Metadata(version.Version); err == nil {
+			version.Digest = metadata.Digest.String()
+			version.InstalledAt = metadata.InstalledAt
+		}
+
+		if v, err := semver.NewVersion(version.Version); err == nil {
+			version.Staged = v.GreaterThan(base)
+		}
+
+		installed = append(installed, version)
+	}
+
+	slices.SortFunc(installed, func(a, b InstalledVersion) int {
+		return compareVersionNames(a.Version, b.Version)
+	})
+
+	return installed, nil
+}
+
+// compareVersionNames orders version directory names by semver precedence.
+// Names that are not valid semver (e.g. "legacy") sort first.
+func compareVersionNames(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+
+	if c := va.Compare(vb); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,106 @@
+package supervisor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStatus(t *testing.T) {
+	source := newFakeSource()
+	source.add("2.0.0", nil, map[string][]byte{"myapp": elfBinary(t)})
+
+	tests := []struct {
+		name          string
+		setup         func(t *testing.T, s *Supervisor)
+		wantActive    string
+		wantInstalled []string
+		wantStaged    []string
+	}{
+		{
+			name:  "fresh installation",
+			setup: func(t *testing.T, s *Supervisor) {},
+		},
+		{
+			name: "legacy installation",
+			setup: func(t *testing.T, s *Supervisor) {
+				if err := os.MkdirAll(s.dataDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "staged without an active version",
+			setup: func(t *testing.T, s *Supervisor) {
+				if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantInstalled: []string{"2.0.0"},
+			wantStaged:    []string{"2.0.0"},
+		},
+		{
+			name: "active version",
+			setup: func(t *testing.T, s *Supervisor) {
+				if _, err := s.Stage(context.Background(), "2.0.0"); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.Symlink(filepath.Join(s.dataDir, "versions", "2.0.0"), filepath.Join(s.dataDir, "current")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantActive:    "2.0.0",
+			wantInstalled: []string{"2.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSupervisor(t, source)
+			tt.setup(t, s)
+
+			status, err := s.Status()
+			if err != nil {
+				t.Fatalf("Status() error = %v", err)
+			}
+
+			if status.Active != tt.wantActive {
+				t.Errorf("Status().Active = %q, want %q", status.Active, tt.wantActive)
+			}
+
+			var installed, staged []string
+
+			for _, v := range status.Installed {
+				installed = append(installed, v.Version)
+
+				if v.Active != (v.Version == tt.wantActive) {
+					t.Errorf("version %s Active = %v", v.Version, v.Active)
+				}
+
+				if v.Staged {
+					staged = append(staged, v.Version)
+				}
+			}
+
+			if !slices.Equal(installed, tt.wantInstalled) {
+				t.Errorf("Status().Installed = %v, want %v", installed, tt.wantInstalled)
+			}
+
+			if !slices.Equal(staged, tt.wantStaged) {
+				t.Errorf("staged versions = %v, want %v", staged, tt.wantStaged)
+			}
+
+			versions, err := s.StagedVersions()
+			if err != nil {
+				t.Fatalf("StagedVersions() error = %v", err)
+			}
+
+			if len(versions) != len(tt.wantStaged) {
+				t.Errorf("StagedVersions() = %v, want %v", versions, tt.wantStaged)
+			}
+		})
+	}
+}
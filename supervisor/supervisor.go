@@ -2,26 +2,42 @@ package supervisor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
 	"sort"
-	"syscall"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/zeitlos/knockknock/config"
 	"github.com/zeitlos/knockknock/oras"
+	"github.com/zeitlos/knockknock/release"
 )
 
 type Supervisor struct {
-	oras oras.Client
+	// source is where releases are discovered and downloaded from
+	source release.Source
 
 	currentVersion *semver.Version
 	config         *config.Config
 
+	// constraint restricts which versions may be installed, nil allows all
+	constraint *semver.Constraints
+
+	// windows restrict when automatic updates are activated, nil allows
+	// any time
+	windows []window
+
+	// rolloutSeed determines the host's bucket in phased rollouts
+	rolloutSeed string
+
 	// dataDir is where versions and symlinks are stored
 	// e.g., /usr/local/lib/my-binary
 	dataDir string
@@ -31,22 +47,55 @@ type Supervisor struct {
 	binPath string
 
 	socketPath string
-}
+	listener   net.Listener
+
+	mu sync.Mutex
+
+	// child is the currently running child process, if any
+	child *child
+
+	// paused is non-nil while the child is intentionally stopped
+	paused chan struct{}
+
+	// ready is closed once the child reported itself healthy
+	ready     chan struct{}
+	readyOnce sync.Once
 
-type HistoricVersion struct {
-	Version       semver.Version
-	LastInstalled time.Time
+	// wake triggers an immediate auto-update poll
+	wake chan struct{}
+
+	// changeMu serializes version changes. It stays locked once a change
+	// succeeded, as the process is about to be replaced.
+	changeMu sync.Mutex
+
+	// staging is the reference currently being staged, if any
+	staging string
+
+	// progress is the state of the running download, if any
+	progress *release.Progress
+
+	progressMu sync.Mutex
+
+	// versionCache holds the versions last listed from the source
+	versionCache versionCache
+
+	stateMu   sync.Mutex
+	journalMu sync.Mutex
 }
 
 const socketEnv = "KNOCKKNOCK_SOCKET"
 
+// legacyVersion is the version directory a binary installed without
+// knockknock is migrated to.
+const legacyVersion = "legacy"
+
 func New(config *config.Config) (*Supervisor, error) {
 	if config.BinaryName == "" {
 		return nil, fmt.Errorf("binary name is required")
 	}
 
-	if config.Repo == "" {
-		return nil, fmt.Errorf("repo is required")
+	if config.Repo == "" && config.Source == nil && config.LayoutDir == "" {
+		return nil, fmt.Errorf("repo, source or layout directory is required")
 	}
 
 	if config.Version == "" {
@@ -62,117 +111,455 @@ func New(config *config.Config) (*Supervisor, error) {
 		return nil, fmt.Errorf("invalid current version '%s': %w", config.Version, err)
 	}
 
-	oras, err := oras.NewClient(config)
+	if err := validateAutoUpdate(config.AutoUpdate); err != nil {
+		return nil, err
+	}
+
+	if err := validateSigningKeys(config.SigningKeys); err != nil {
+		return nil, err
+	}
+
+	defaultProbation(config.Probation)
+
+	defaultShutdown(config)
+
+	if err := validateChannel(config); err != nil {
+		return nil, err
+	}
+
+	constraint, err := parseConstraint(config.Constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := parseMaintenance(config.Maintenance)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := rolloutSeed(config)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := newSource(config)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Supervisor{
-		oras:           *oras,
+		source:         source,
 		config:         config,
 		currentVersion: currentVersion,
+		constraint:     constraint,
+		windows:        windows,
+		rolloutSeed:    seed,
 		dataDir:        filepath.Join(config.VersionsDir, config.BinaryName),
 		binPath:        filepath.Join(config.BinaryDir, config.BinaryName),
-		socketPath:     fmt.Sprintf("/tmp/knockknock-%d.sock", os.Getpid()),
+		socketPath:     SocketPath(),
+		ready:          make(chan struct{}),
+		wake:           make(chan struct{}, 1),
 	}, nil
 }
 
+// newSource returns the configured source, the configured image layouts, or
+// an OCI registry client for the configured repository and its mirrors.
+func newSource(config *config.Config) (release.Source, error) {
+	switch {
+	case config.Source != nil:
+		return config.Source, nil
+	case config.LayoutDir != "":
+		return oras.NewLayoutSource(config, config.LayoutDir)
+	case len(config.Mirrors) > 0:
+		return oras.NewMirrorSource(config)
+	default:
+		return oras.NewClient(config)
+	}
+}
+
+func validateAutoUpdate(autoUpdate *config.AutoUpdateConfig) error {
+	if autoUpdate == nil || !autoUpdate.Enabled {
+		return nil
+	}
+
+	if autoUpdate.Interval <= 0 {
+		return fmt.Errorf("auto-update interval must be positive")
+	}
+
+	switch autoUpdate.Policy {
+	case config.UpdatePolicyLatest, config.UpdatePolicyMinor, config.UpdatePolicyPatch:
+	default:
+		return fmt.Errorf("unknown auto-update policy '%s'", autoUpdate.Policy)
+	}
+
+	return nil
+}
+
 func (s *Supervisor) CurrentVersion() *semver.Version {
 	return s.currentVersion
 }
 
+// CheckForUpdate returns the versions of the active channel in ascending
+// order, and the highest of them newer than the current version that is
+// eligible for installation, if any. Versions in a phased rollout are only
+// offered once the rollout includes this host. The version list is cached,
+// see RefreshVersions.
 func (s *Supervisor) CheckForUpdate(ctx context.Context) (update *semver.Version, allVersions []semver.Version, err error) {
-	allVersions, err = s.oras.Versions(ctx)
+	versions, err := s.versions(ctx)
 	if err != nil {
 		return
 	}
 
-	if len(allVersions) == 0 {
-		err = fmt.Errorf("no versions found in repository")
+	if versions.Len() == 0 {
+		err = fmt.Errorf("no versions found in source")
 		return
 	}
 
-	latest := allVersions[len(allVersions)-1]
+	channel := s.Channel()
+	versions = filterChannel(channel, versions)
 
-	if latest.GreaterThan(s.currentVersion) {
-		update = &latest
+	if versions.Len() == 0 {
+		err = fmt.Errorf("no versions found in channel '%s'", channel.Name)
 		return
 	}
 
+	allVersions = versions.Versions()
+
+	candidates, err := s.candidates(versions)
+	if err != nil {
+		return
+	}
+
+	update, err = s.newestRolledOut(ctx, candidates)
+
 	return
 }
 
-func (s *Supervisor) Update(ctx context.Context, version string) error {
+// candidates narrows versions down to those that may be installed without
+// forcing: within the configured constraint and not quarantined.
+func (s *Supervisor) candidates(versions release.VersionSet) (release.VersionSet, error) {
+	return s.withoutQuarantined(s.withinConstraint(versions))
+}
+
+// Update downloads and activates a version. The reference is either a
+// version tag ("1.2.3"), a digest pinned version ("1.2.3@sha256:..."), or
+// the configured repository followed by a tag or digest
+// ("ghcr.io/org/repo@sha256:..."). For digest-only references the version
+// is taken from the manifest's org.opencontainers.image.version annotation.
+// Quarantined versions and versions outside the configured constraint are
+// refused.
+func (s *Supervisor) Update(ctx context.Context, reference string) error {
+	return s.update(ctx, reference, TriggerManual, false)
+}
+
+// ForceUpdate is like Update but also installs quarantined versions and
+// versions rejected by the configured constraint.
+func (s *Supervisor) ForceUpdate(ctx context.Context, reference string) error {
+	return s.update(ctx, reference, TriggerManual, true)
+}
+
+// update installs the referenced version, records the outcome in the journal
+// and restarts into the new version.
+func (s *Supervisor) update(ctx context.Context, reference string, trigger Trigger, force bool) error {
+	if err := s.beginChange(); err != nil {
+		return err
+	}
+
+	entry := s.newJournalEntry(ActionUpdate, trigger)
+	entry.To = reference
+
+	err := s.install(ctx, reference, entry, force)
+	s.record(entry, err)
+
+	if err != nil {
+		s.changeMu.Unlock()
+		return err
+	}
+
+	return s.restart()
+}
+
+// install downloads, verifies and activates the referenced version. The
+// resolved version and digest are filled into the journal entry.
+func (s *Supervisor) install(ctx context.Context, reference string, entry *JournalEntry, force bool) error {
+	version, err := s.download(ctx, reference, entry, force)
+	if err != nil {
+		return err
+	}
+
+	if err := s.switchVersion(version); err != nil {
+		return err
+	}
+
+	if err := s.startProbation(version); err != nil {
+		slog.Warn("failed to start probation", "version", version, "error", err)
+	}
+
+	return nil
+}
+
+// download resolves the referenced version and downloads and verifies it into
+// the versions directory, without activating it. Versions that are already
+// present with the same digest are not downloaded again. The resolved
+// version and digest are filled into the journal entry.
+func (s *Supervisor) download(ctx context.Context, reference string, entry *JournalEntry, force bool) (string, error) {
+	version, ref, err := s.parseReference(reference)
+	if err != nil {
+		return "", err
+	}
+
+	artifact, err := s.source.Resolve(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	if version == "" {
+		version = artifact.Annotations[ocispec.AnnotationVersion]
+
+		if version == "" {
+			return "", fmt.Errorf("%s has no %s annotation, specify the version as <version>@<digest>", reference, ocispec.AnnotationVersion)
+		}
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return "", fmt.Errorf("invalid version '%s': %w", version, err)
+	}
+
+	// "v1.2.3" and "1.2.3" are the same version and share one directory
+	version = v.String()
+
+	if err := checkVersionLabel(version, artifact); err != nil {
+		return "", err
+	}
+
+	entry.To = version
+	entry.Digest = artifact.Digest.String()
+
+	if err := s.checkVersion(version, force); err != nil {
+		return "", err
+	}
+
+	entrypoint, err := s.entrypoint(artifact)
+	if err != nil {
+		return "", err
+	}
+
+	if metadata, err := s.Metadata(version); err == nil && metadata.Digest == artifact.Digest {
+		if err := s.verifyVersion(version); err == nil {
+			// The version might have been downloaded before signing keys
+			// were configured
+			if err := s.verifySignature(ctx, artifact); err != nil {
+				return "", fmt.Errorf("signature verification failed: %w", err)
+			}
+
+			slog.Info("version already downloaded", "version", version, "digest", artifact.Digest)
+			return version, nil
+		}
+	}
+
+	if active, err := s.activeVersion(); err == nil && active == version {
+		return "", fmt.Errorf("version %s is active with different content, refusing to overwrite it", version)
+	}
+
+	// Only the partial files of this download can still be resumed
+	if err := release.PruneDownloads(s.downloadOptions().TempDir, artifact.Files); err != nil {
+		slog.Warn("failed to prune partial downloads", "error", err)
+	}
+
 	versionsDir := filepath.Join(s.dataDir, "versions")
 
 	if err := os.MkdirAll(versionsDir, 0755); err != nil {
-		return fmt.Errorf("failed to create versions directory: %w", err)
+		return "", fmt.Errorf("failed to create versions directory: %w", err)
 	}
 
-	versionDir := filepath.Join(versionsDir, version)
+	// The version is assembled next to the versions directory and only
+	// moved into it once it passed verification, so that versions/<v> never
+	// holds a partial or untrusted download. Downloads are serialized by the
+	// change lock, so any directory left over was abandoned by a crash.
+	if leftovers, err := filepath.Glob(filepath.Join(s.dataDir, "download-*")); err == nil {
+		for _, dir := range leftovers {
+			os.RemoveAll(dir)
+		}
+	}
 
-	if err := os.MkdirAll(versionDir, 0755); err != nil {
-		return fmt.Errorf("failed to create version directory: %w", err)
+	tempDir, err := os.MkdirTemp(s.dataDir, "download-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
 	}
+	defer os.RemoveAll(tempDir)
 
-	if err := s.oras.DownloadUpdate(ctx, version, versionDir); err != nil {
-		return fmt.Errorf("failed to download version %s: %w", version, err)
+	if err := os.Chmod(tempDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to chmod download directory: %w", err)
 	}
 
-	binaryPath := filepath.Join(versionDir, s.config.BinaryName)
+	defer s.clearProgress()
+
+	if err := s.downloadDelta(ctx, artifact, tempDir); err != nil {
+		if !errors.Is(err, errNoPatch) {
+			slog.Warn("delta update failed, falling back to full download", "version", version, "error", err)
+		}
+
+		if err := release.Download(ctx, s.source, artifact, tempDir, s.downloadOptions()); err != nil {
+			return "", fmt.Errorf("failed to download version %s: %w", version, err)
+		}
+	}
+
+	if err := s.linkEntrypoint(tempDir, entrypoint); err != nil {
+		return "", err
+	}
+
+	metadata := newMetadata(version, artifact)
+
+	// Every declared file must be in place before the version can be
+	// activated
+	if err := verifyFiles(tempDir, metadata.Files); err != nil {
+		return "", fmt.Errorf("file verification failed: %w", err)
+	}
+
+	binaryPath := filepath.Join(tempDir, s.config.BinaryName)
 
 	if err := verifyBinary(binaryPath); err != nil {
-		return fmt.Errorf("binary verification failed: %w", err)
+		return "", fmt.Errorf("binary verification failed: %w", err)
 	}
 
-	currentLink := filepath.Join(s.dataDir, "current")
+	if err := s.verifySignature(ctx, artifact); err != nil {
+		return "", fmt.Errorf("signature verification failed: %w", err)
+	}
 
-	// Backup existing current symlink if it exists
-	if _, err := os.Lstat(currentLink); err == nil {
-		timestamp := time.Now().Format("20060102-150405")
-		backupLink := filepath.Join(s.dataDir, fmt.Sprintf("previous-%s", timestamp))
+	if err := s.installVersion(tempDir, metadata); err != nil {
+		return "", err
+	}
 
-		target, err := os.Readlink(currentLink)
-		if err != nil {
-			return fmt.Errorf("failed to read current symlink: %w", err)
-		}
+	slog.Info("downloaded version", "version", version, "digest", artifact.Digest)
 
-		if err := os.Symlink(target, backupLink); err != nil {
-			return fmt.Errorf("failed to create backup symlink: %w", err)
-		}
+	return version, nil
+}
+
+// installVersion moves a verified version directory into the versions
+// directory, replacing an earlier download of the same version. The metadata
+// is written first: a version directory without metadata is never accepted
+// for activation.
+func (s *Supervisor) installVersion(dir string, metadata *Metadata) error {
+	versionDir := filepath.Join(s.dataDir, "versions", metadata.Version)
+
+	if err := os.RemoveAll(versionDir); err != nil {
+		return fmt.Errorf("failed to remove previous download of version %s: %w", metadata.Version, err)
 	}
 
-	// Atomically swap the current symlink to point to new version
-	tempLink := filepath.Join(s.dataDir, fmt.Sprintf("current.tmp.%d", time.Now().Unix()))
+	if err := s.writeMetadata(metadata); err != nil {
+		return err
+	}
 
-	if err := os.Symlink(versionDir, tempLink); err != nil {
-		return fmt.Errorf("failed to create temporary symlink: %w", err)
+	if err := os.Rename(dir, versionDir); err != nil {
+		os.Remove(s.metadataPath(metadata.Version))
+		return fmt.Errorf("failed to move version %s into place: %w", metadata.Version, err)
 	}
 
-	if err := os.Rename(tempLink, currentLink); err != nil {
-		os.Remove(tempLink)
-		return fmt.Errorf("failed to swap symlink: %w", err)
+	return nil
+}
+
+// ValidateUpdate checks whether the referenced version may be installed
+// without contacting the registry. It is meant as a preflight check before
+// an update is started in the background; Update performs the same checks.
+func (s *Supervisor) ValidateUpdate(reference string, force bool) error {
+	version, _, err := s.parseReference(reference)
+	if err != nil {
+		return err
 	}
 
-	// Update the binary symlink in the bin directory
-	if err := s.updateBinSymlink(); err != nil {
-		return fmt.Errorf("failed to update bin symlink: %w", err)
+	if version == "" {
+		// Only known once the digest is resolved
+		return nil
+	}
+
+	return s.checkVersion(version, force)
+}
+
+// checkVersionLabel rejects artifacts whose version annotation contradicts
+// the version they are installed as. All update rules are applied to that
+// version, so a digest pinned reference must not relabel another release.
+func checkVersionLabel(version string, artifact *release.Artifact) error {
+	annotated, ok := artifact.Annotations[ocispec.AnnotationVersion]
+	if !ok || annotated == version {
+		return nil
+	}
+
+	a, err := semver.NewVersion(annotated)
+	if err == nil && a.Equal(semver.MustParse(version)) {
+		return nil
+	}
+
+	return fmt.Errorf("%s is annotated as version %s, not %s", artifact.Reference, annotated, version)
+}
+
+// checkVersion enforces the update rules for a version unless forced.
+func (s *Supervisor) checkVersion(version string, force bool) error {
+	if force {
+		return nil
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("invalid version '%s': %w", version, err)
 	}
 
-	if err := s.cleanupOldBackups(3); err != nil {
-		slog.Warn("failed to cleanup old backups", "error", err)
+	if err := s.checkConstraint(v); err != nil {
+		return err
 	}
 
-	// Kill the current process - systemd will restart it with the new version
-	pid := os.Getpid()
+	q, err := s.quarantined(version)
+	if err != nil {
+		return err
+	}
 
-	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
-		return fmt.Errorf("failed to send termination signal: %w", err)
+	if q != nil {
+		return fmt.Errorf("version %s is quarantined since %s (%s), force the update to install it anyway", version, q.Since.Format(time.DateTime), q.Reason)
 	}
 
 	return nil
 }
 
+// parseReference splits an update reference into the version and the
+// reference to resolve in the source. The version is empty for references
+// that only consist of a digest.
+func (s *Supervisor) parseReference(reference string) (version, ref string, err error) {
+	if rest, ok := strings.CutPrefix(reference, s.config.Repo); ok && s.config.Repo != "" {
+		switch {
+		case strings.HasPrefix(rest, "@"):
+			return "", rest[1:], nil
+		case strings.HasPrefix(rest, ":"):
+			reference = rest[1:]
+		default:
+			return "", "", fmt.Errorf("invalid reference '%s'", reference)
+		}
+	} else if strings.Contains(reference, "/") {
+		return "", "", fmt.Errorf("reference '%s' does not belong to repository %s", reference, s.config.Repo)
+	}
+
+	if version, pin, ok := strings.Cut(reference, "@"); ok {
+		return canonicalVersion(version), pin, nil
+	}
+
+	if strings.HasPrefix(reference, "sha256:") {
+		return "", reference, nil
+	}
+
+	return canonicalVersion(reference), reference, nil
+}
+
+// canonicalVersion returns the canonical spelling of a version, which names
+// its directory and quarantine entry whichever tag it was published under.
+// Names that are not valid semver are returned unchanged.
+func canonicalVersion(version string) string {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return version
+	}
+
+	return v.String()
+}
+
 // updateBinSymlink atomically updates the binary symlink in the bin directory
 // to point to the current version's binary. If the existing binary is a regular
 // file (legacy installation), it will be migrated to the versions directory.
@@ -212,7 +599,7 @@ func (s *Supervisor) updateBinSymlink() error {
 func (s *Supervisor) migrateLegacyBinary() error {
 	slog.Info("migrating legacy binary installation", "path", s.binPath)
 
-	legacyVersionDir := filepath.Join(s.dataDir, "versions", "legacy")
+	legacyVersionDir := filepath.Join(s.dataDir, "versions", legacyVersion)
 
 	if err := os.MkdirAll(legacyVersionDir, 0755); err != nil {
 		return fmt.Errorf("failed to create legacy version directory: %w", err)
@@ -238,7 +625,33 @@ func (s *Supervisor) migrateLegacyBinary() error {
 	return nil
 }
 
+// Rollback switches back to the most recently replaced version.
 func (s *Supervisor) Rollback() error {
+	return s.rollback(TriggerManual)
+}
+
+// rollback reverts to the previous version, records the outcome in the
+// journal and restarts into it.
+func (s *Supervisor) rollback(trigger Trigger) error {
+	if err := s.beginChange(); err != nil {
+		return err
+	}
+
+	entry := s.newJournalEntry(ActionRollback, trigger)
+
+	err := s.revert(entry)
+	s.record(entry, err)
+
+	if err != nil {
+		s.changeMu.Unlock()
+		return err
+	}
+
+	return s.restart()
+}
+
+// revert points the current symlink back to the most recent backup.
+func (s *Supervisor) revert(entry *JournalEntry) error {
 	backups, err := s.getBackupSymlinks()
 	if err != nil {
 		return fmt.Errorf("failed to find backup symlinks: %w", err)
@@ -256,10 +669,18 @@ func (s *Supervisor) Rollback() error {
 		return fmt.Errorf("failed to read backup symlink: %w", err)
 	}
 
-	binaryPath := filepath.Join(target, s.config.BinaryName)
+	entry.To = filepath.Base(target)
 
-	if err := verifyBinary(binaryPath); err != nil {
-		return fmt.Errorf("backup version binary verification failed: %w", err)
+	if metadata, err := s.Metadata(entry.To); err == nil {
+		entry.Digest = metadata.Digest.String()
+	}
+
+	if err := s.verifyVersion(filepath.Base(target)); err != nil {
+		if !errors.Is(err, ErrNoMetadata) {
+			return fmt.Errorf("backup version verification failed: %w", err)
+		}
+
+		slog.Warn("backup version has no integrity metadata, skipping digest verification", "version", filepath.Base(target))
 	}
 
 	currentLink := filepath.Join(s.dataDir, "current")
@@ -283,71 +704,9 @@ func (s *Supervisor) Rollback() error {
 		slog.Warn("failed to remove backup symlink", "symlink", latestBackup, "error", err)
 	}
 
-	pid := os.Getpid()
-
-	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
-		return fmt.Errorf("failed to send termination signal: %w", err)
-	}
-
 	return nil
 }
 
-func (s *Supervisor) History() []HistoricVersion {
-	backups, err := s.getBackupSymlinks()
-
-	if err != nil {
-		return []HistoricVersion{}
-	}
-
-	var history []HistoricVersion
-
-	for _, backup := range backups {
-		target, err := os.Readlink(backup)
-
-		if err != nil {
-			continue
-		}
-
-		versionName := filepath.Base(target)
-		version, err := semver.NewVersion(versionName)
-
-		if err != nil {
-			// Could be "legacy" version - handle specially
-			if versionName == "legacy" {
-				history = append(history, HistoricVersion{
-					Version:       *semver.MustParse("0.0.0-legacy"),
-					LastInstalled: parsePreviousTimestamp(filepath.Base(backup)),
-				})
-			}
-			continue
-		}
-
-		history = append(history, HistoricVersion{
-			Version:       *version,
-			LastInstalled: parsePreviousTimestamp(filepath.Base(backup)),
-		})
-	}
-
-	// Sort by last installed date in descending order (most recent first)
-	sort.Slice(history, func(i, j int) bool {
-		return history[i].LastInstalled.After(history[j].LastInstalled)
-	})
-
-	return history
-}
-
-// parsePreviousTimestamp extracts the timestamp from a backup symlink name
-// (format: previous-20060102-150405)
-func parsePreviousTimestamp(name string) time.Time {
-	if len(name) > 9 && name[:9] == "previous-" {
-		timestamp := name[9:]
-		if t, err := time.Parse("20060102-150405", timestamp); err == nil {
-			return t
-		}
-	}
-	return time.Time{}
-}
-
 // getBackupSymlinks returns a sorted list of backup symlink paths
 func (s *Supervisor) getBackupSymlinks() ([]string, error) {
 	entries, err := os.ReadDir(s.dataDir)
@@ -423,5 +782,5 @@ func verifyBinary(path string) error {
 		return fmt.Errorf("binary is not a valid ELF file")
 	}
 
-	return nil
+	return verifyMachine(file)
 }
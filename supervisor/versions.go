@@ -0,0 +1,135 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/zeitlos/knockknock/release"
+	"golang.org/x/sync/singleflight"
+)
+
+// versionCache holds the versions last listed from the source, so that
+// frequent update checks, e.g. on every page load of a dashboard, don't
+// each list all tags of the registry.
+type versionCache struct {
+	mu sync.Mutex
+
+	versions release.VersionSet
+	cached   bool
+
+	// err is the error of the last refresh, if it failed
+	err error
+
+	// checkedAt is when the source was last asked, successfully or not.
+	// Failed refreshes are throttled like successful ones.
+	checkedAt time.Time
+
+	// group deduplicates concurrent refreshes
+	group singleflight.Group
+
+	// generation counts the successful refreshes
+	generation int
+
+	// rollouts holds the rollout percentages of versions by their
+	// canonical spelling, see rolloutPercentage
+	rollouts map[string]rollout
+}
+
+// rollout is the rollout percentage of a version, as resolved while the
+// given generation of the version list was current.
+type rollout struct {
+	percentage int
+	generation int
+}
+
+// versions returns the cached versions. An expired list is served as is
+// while it is refreshed in the background; the source is only waited for
+// if nothing is cached yet, or if caching is disabled.
+func (s *Supervisor) versions(ctx context.Context) (release.VersionSet, error) {
+	c := &s.versionCache
+
+	c.mu.Lock()
+	versions, cached, checkedAt, lastErr := c.versions, c.cached, c.checkedAt, c.err
+	c.mu.Unlock()
+
+	ttl := s.config.VersionCacheTTL
+
+	switch {
+	case !cached && lastErr != nil && time.Since(checkedAt) < ttl:
+		return release.VersionSet{}, fmt.Errorf("failed to list versions: %w", lastErr)
+	case !cached:
+		return s.refreshVersions(ctx)
+	case time.Since(checkedAt) < ttl:
+		return versions, nil
+	case ttl <= 0:
+		refreshed, err := s.refreshVersions(ctx)
+		if err != nil {
+			slog.Warn("failed to refresh versions, using cached versions", "age", time.Since(checkedAt), "error", err)
+			return versions, nil
+		}
+
+		return refreshed, nil
+	}
+
+	go func() {
+		if _, err := s.refreshVersions(context.Background()); err != nil {
+			slog.Warn("failed to refresh versions, using cached versions", "error", err)
+		}
+	}()
+
+	return versions, nil
+}
+
+// RefreshVersions lists the versions from the source right away, replacing
+// the cached list. Concurrent refreshes share a single request.
+func (s *Supervisor) RefreshVersions(ctx context.Context) error {
+	_, err := s.refreshVersions(ctx)
+	return err
+}
+
+func (s *Supervisor) refreshVersions(ctx context.Context) (release.VersionSet, error) {
+	c := &s.versionCache
+
+	// The request is shared, so it must not be canceled along with the
+	// caller that happened to start it
+	result := c.group.DoChan("versions", func() (any, error) {
+		versions, err := s.source.Versions(context.WithoutCancel(ctx))
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.checkedAt = time.Now()
+		c.err = err
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.versions = versions
+		c.cached = true
+		c.generation++
+
+		for version := range c.rollouts {
+			if _, ok := versions.Find(semver.MustParse(version)); !ok {
+				delete(c.rollouts, version)
+			}
+		}
+
+		return versions, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return release.VersionSet{}, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return release.VersionSet{}, fmt.Errorf("failed to list versions: %w", res.Err)
+		}
+
+		return res.Val.(release.VersionSet), nil
+	}
+}